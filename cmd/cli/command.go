@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/game/rules"
+	"upgrade-lan/internal/room"
+)
+
+// 只在本地处理、不发给服务端的命令
+const (
+	cmdHelp = "help"
+	cmdShow = "show"
+)
+
+const helpText = `命令（牌用简写：S/H/C/D + 点数，如 S10 HA D2；小王 LJ、大王 BJ；也可写 #ID）
+  大厅    sit <座位>  leave  ready  unready  start [force]  bot <座位>  unbot <座位>
+  定主    call <王> <级牌> [级牌]   pass
+  扣底    bury <8张牌>
+  改/攻主 change <王> <级牌> <级牌>   attack <王> <王>   pass
+  出牌    play <牌...>
+  结算    next（开始下一小局）  rematch（再来一局）
+  其他    say <文字>  undo  yes  no  follow <座位|-1>  show  raw <事件类型> [JSON]  help  quit
+`
+
+type command struct {
+	typ     string // 发给服务端的事件类型，或 cmdHelp / cmdShow
+	payload any
+}
+
+// parseCommand 把一行输入翻译为事件；出牌类命令需要最新的 view 把牌面简写解析为 ID
+func parseCommand(line string, view *game.ViewState) (command, error) {
+	fields := strings.Fields(line)
+	name, args := strings.ToLower(fields[0]), fields[1:]
+	empty := struct{}{}
+
+	switch name {
+	case "help", "?":
+		return command{typ: cmdHelp}, nil
+	case "show", "v":
+		return command{typ: cmdShow}, nil
+
+	case "sit":
+		seat, err := seatArg(args)
+		return command{typ: string(game.EvSit), payload: game.SitPayload{Seat: seat}}, err
+	case "leave":
+		return command{typ: string(game.EvLeave), payload: empty}, nil
+	case "ready":
+		return command{typ: string(game.EvReady), payload: empty}, nil
+	case "unready":
+		return command{typ: string(game.EvUnready), payload: empty}, nil
+	case "start":
+		force := len(args) > 0 && args[0] == "force"
+		return command{typ: string(game.EvStart), payload: game.StartPayload{Force: force}}, nil
+	case "bot":
+		seat, err := seatArg(args)
+		return command{typ: room.CmdAddBot, payload: game.SitPayload{Seat: seat}}, err
+	case "unbot":
+		seat, err := seatArg(args)
+		return command{typ: room.CmdRemoveBot, payload: game.SitPayload{Seat: seat}}, err
+	case "next":
+		return command{typ: string(game.EvStartNextRound), payload: empty}, nil
+	case "rematch":
+		return command{typ: string(game.EvRematch), payload: empty}, nil
+
+	case "pass":
+		return command{typ: string(game.EvCallPass), payload: empty}, nil
+	case "call", "change":
+		ids, err := pickCards(view, args)
+		if err != nil {
+			return command{}, err
+		}
+		if len(ids) < 2 {
+			return command{}, fmt.Errorf("用法：%s <王> <级牌...>", name)
+		}
+		if name == "call" {
+			return command{typ: string(game.EvCallTrump), payload: game.CallTrumpPayload{JokerID: ids[0], LevelIDs: ids[1:]}}, nil
+		}
+		return command{typ: string(game.EvChangeTrump), payload: game.ChangeTrumpPayload{JokerID: ids[0], LevelIDs: ids[1:]}}, nil
+	case "attack":
+		ids, err := pickCards(view, args)
+		return command{typ: string(game.EvAttackTrump), payload: game.AttackTrumpPayload{JokerIDs: ids}}, err
+	case "bury":
+		ids, err := pickCards(view, args)
+		return command{typ: string(game.EvPutBottom), payload: game.PutBottomPayload{DiscardIDs: ids}}, err
+	case "play":
+		ids, err := pickCards(view, args)
+		return command{typ: string(game.EvPlayCards), payload: game.PlayCardsPayload{CardIDs: ids}}, err
+
+	case "say":
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+		return command{typ: string(game.EvChat), payload: game.ChatPayload{Text: text}}, nil
+	case "undo":
+		return command{typ: room.CmdProposeUndo, payload: empty}, nil
+	case "yes":
+		return command{typ: room.CmdAcceptUndo, payload: empty}, nil
+	case "no":
+		return command{typ: room.CmdRejectUndo, payload: empty}, nil
+	case "follow":
+		if len(args) == 0 {
+			return command{}, fmt.Errorf("用法：follow <座位|-1>")
+		}
+		seat, err := strconv.Atoi(args[0])
+		if err != nil || seat < -1 || seat > 3 {
+			return command{}, fmt.Errorf("座位应为 0~3，-1 取消跟随")
+		}
+		return command{typ: room.CmdFollow, payload: room.FollowPayload{Seat: seat}}, nil
+	case "raw":
+		if len(args) == 0 {
+			return command{}, fmt.Errorf("用法：raw <事件类型> [JSON]")
+		}
+		raw := json.RawMessage("{}")
+		if len(args) > 1 {
+			rest := strings.TrimSpace(line[strings.Index(line, args[0])+len(args[0]):])
+			if !json.Valid([]byte(rest)) {
+				return command{}, fmt.Errorf("payload 不是合法的 JSON")
+			}
+			raw = json.RawMessage(rest)
+		}
+		return command{typ: args[0], payload: raw}, nil
+	}
+	return command{}, fmt.Errorf("未知命令 %s（输入 help 查看命令）", name)
+}
+
+func seatArg(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, fmt.Errorf("缺少座位号")
+	}
+	seat, err := strconv.Atoi(args[0])
+	if err != nil || seat < 0 || seat > 3 {
+		return 0, fmt.Errorf("座位应为 0~3")
+	}
+	return seat, nil
+}
+
+// pickCards 把牌面简写解析为卡牌 ID：在手牌（扣底阶段含底牌）中按顺序取第一张未被选中的同名牌
+// 简写不区分大小写，10 也可写作 T（如 ST）；#ID 直接指定某一张
+func pickCards(view *game.ViewState, tokens []string) ([]int, error) {
+	if view == nil {
+		return nil, fmt.Errorf("还没有收到快照")
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("没有指定牌")
+	}
+	var pool []rules.Card
+	for _, group := range view.MyHand {
+		pool = append(pool, group...)
+	}
+	pool = append(pool, view.MyBottom...)
+
+	used := make(map[int]bool, len(tokens))
+	ids := make([]int, 0, len(tokens))
+	for _, tok := range tokens {
+		id, ok := -1, false
+		if n, found := strings.CutPrefix(tok, "#"); found {
+			v, err := strconv.Atoi(n)
+			if err != nil {
+				return nil, fmt.Errorf("无法识别的牌 %s", tok)
+			}
+			for _, c := range pool {
+				if c.ID == v && !used[v] {
+					id, ok = v, true
+					break
+				}
+			}
+		} else {
+			face := normalizeFace(tok)
+			for _, c := range pool {
+				if !used[c.ID] && game.CardFace(c) == face {
+					id, ok = c.ID, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("手里没有 %s", tok)
+		}
+		used[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func normalizeFace(tok string) string {
+	face := strings.ToUpper(tok)
+	if len(face) == 2 && face[1] == 'T' {
+		face = face[:1] + "10"
+	}
+	return face
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	"upgrade-lan/internal/game"
+)
+
+// cli 终端客户端：不依赖浏览器，直接连 /ws 打牌
+//
+//	go run ./cmd/cli -room r1 -uid alice -create # 服务端 -open-lan 时直接指定 uid；-create 先建房
+//	go run ./cmd/cli -room r1 -name alice       # 先 POST /session 领取 token
+//	go run ./cmd/cli -room r1 -token <token> -lang en
+//
+// 连上后输入 help 查看命令；牌用简写（S10、HA、LJ、BJ，或 #ID），同名的两张牌依次选取
+func main() {
+	server := flag.String("server", "localhost:8080", "服务器地址 host:port")
+	roomID := flag.String("room", "default", "房间号")
+	uid := flag.String("uid", "", "uid（仅服务端 -open-lan 时有效）")
+	token := flag.String("token", "", "会话 token")
+	name := flag.String("name", "", "昵称：没有 token 时先向 /session 领取")
+	password := flag.String("password", "", "私密房间密码")
+	invite := flag.String("invite", "", "私密房间一次性邀请")
+	spectate := flag.Bool("spectate", false, "以观战身份加入")
+	lang := flag.String("lang", "zh-CN", "通知语言（zh-CN / en）")
+	create := flag.Bool("create", false, "连接前先创建房间（已存在则直接加入），创建者成为房主")
+	flag.Parse()
+
+	if *token == "" && *name != "" {
+		t, err := fetchToken(*server, *name)
+		if err != nil {
+			log.Fatalf("领取会话 token 失败: %v", err)
+		}
+		*token = t
+	}
+
+	if *create {
+		if err := createRoom(*server, *roomID, *password, *uid, *token); err != nil {
+			log.Fatalf("创建房间失败: %v", err)
+		}
+	}
+
+	q := url.Values{}
+	q.Set("room", *roomID)
+	q.Set("lang", *lang)
+	for k, v := range map[string]string{"uid": *uid, "token": *token, "password": *password, "invite": *invite} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if *spectate {
+		q.Set("spectate", "1")
+	}
+	u := url.URL{Scheme: "ws", Host: *server, Path: "/ws", RawQuery: q.Encode()}
+	ws, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		log.Fatalf("连接 %s 失败: %v", u.Host, err)
+	}
+	c := &client{ws: ws}
+	defer c.close()
+
+	c.printf("已连接 %s 房间 %s（输入 help 查看命令）\n", u.Host, *roomID)
+	go c.readLoop()
+
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if line == "quit" || line == "exit" {
+			return
+		}
+		c.handleLine(line)
+	}
+}
+
+// fetchToken POST /session 领取 token（uid 由服务端生成：昵称#随机后缀）
+func fetchToken(server, name string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"name": name})
+	resp, err := http.Post("http://"+server+"/session", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var out struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	return out.Token, nil
+}
+
+// createRoom POST /rooms 创建房间并成为房主；房间已存在（409）时直接加入
+func createRoom(server, id, password, uid, token string) error {
+	body, _ := json.Marshal(map[string]string{"id": id, "password": password})
+	q := url.Values{}
+	if token != "" {
+		q.Set("token", token)
+	} else if uid != "" {
+		q.Set("uid", uid)
+	}
+	resp, err := http.Post("http://"+server+"/rooms?"+q.Encode(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusConflict:
+		return nil
+	}
+	var e game.AppError
+	if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Code != "" {
+		return &e
+	}
+	return fmt.Errorf("HTTP %d", resp.StatusCode)
+}
+
+// client 读循环（收消息、打印）与主循环（读命令、发送）共享最新的 view；输出加锁避免两边交错
+type client struct {
+	ws     *websocket.Conn
+	mu     sync.Mutex
+	view   *game.ViewState
+	reqSeq int
+	closed bool // 主动退出，读循环不再报告断开
+}
+
+func (c *client) close() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	_ = c.ws.Close()
+}
+
+func (c *client) printf(format string, a ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Printf(format, a...)
+}
+
+func (c *client) readLoop() {
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if !c.closed {
+				fmt.Printf("连接已断开: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		c.dispatch(data)
+	}
+}
+
+func (c *client) dispatch(data []byte) {
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return
+	}
+	switch head.Type {
+	case "snapshot":
+		var m game.Snapshot
+		if json.Unmarshal(data, &m) != nil {
+			return
+		}
+		c.mu.Lock()
+		c.view = &m.State
+		fmt.Print(renderView(&m.State))
+		c.mu.Unlock()
+	case "notice":
+		var m game.NoticeMsg
+		if json.Unmarshal(data, &m) == nil {
+			c.printf("» %s\n", m.Message)
+		}
+	case "error":
+		var m game.ErrorMsg
+		if json.Unmarshal(data, &m) == nil {
+			msg := m.Message
+			if m.Info != "" {
+				msg = m.Info
+			}
+			c.printf("✗ [%s] %s\n", m.Code, msg)
+		}
+	case "chat":
+		var m game.ChatMsg
+		if json.Unmarshal(data, &m) == nil {
+			c.printf("%s\n", renderChat(m))
+		}
+	case "chat_history":
+		var m game.ChatHistoryMsg
+		if json.Unmarshal(data, &m) == nil {
+			for _, msg := range m.Messages {
+				c.printf("%s\n", renderChat(msg))
+			}
+		}
+	}
+	// ack / event / delta：ack 失败时已有 error 消息，事件只用于前端动效，未开启差量
+}
+
+// handleLine 解析一行命令并发送；本地解析失败直接提示，不发给服务端
+func (c *client) handleLine(line string) {
+	c.mu.Lock()
+	view := c.view
+	c.mu.Unlock()
+
+	cmd, err := parseCommand(line, view)
+	if err != nil {
+		c.printf("✗ %v\n", err)
+		return
+	}
+	switch cmd.typ {
+	case "":
+		return
+	case cmdHelp:
+		c.printf("%s", helpText)
+		return
+	case cmdShow:
+		if view == nil {
+			c.printf("还没有收到快照\n")
+			return
+		}
+		c.printf("%s", renderView(view))
+		return
+	}
+
+	payload, ok := cmd.payload.(json.RawMessage)
+	if !ok {
+		b, err := json.Marshal(cmd.payload)
+		if err != nil {
+			c.printf("✗ %v\n", err)
+			return
+		}
+		payload = b
+	}
+	c.reqSeq++
+	msg := map[string]any{"type": cmd.typ, "reqId": "cli-" + strconv.Itoa(c.reqSeq), "payload": payload}
+	if err := c.ws.WriteJSON(msg); err != nil {
+		c.printf("✗ 发送失败: %v\n", err)
+	}
+}
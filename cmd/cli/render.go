@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/game/rules"
+)
+
+var phaseNames = map[game.Phase]string{
+	game.PhaseLobby:       "大厅",
+	game.PhaseDealing:     "发牌",
+	game.PhaseCallTrump:   "定主",
+	game.PhaseBottom:      "扣底",
+	game.PhaseTrumpFight:  "改主/攻主",
+	game.PhasePlayTrick:   "出牌",
+	game.PhaseRoundSettle: "小局结算",
+	game.PhaseGameOver:    "整局结束",
+}
+
+var suitNames = map[rules.Suit]string{
+	rules.Spade:   "黑桃",
+	rules.Heart:   "红桃",
+	rules.Club:    "梅花",
+	rules.Diamond: "方块",
+}
+
+// renderView 把一份快照渲染为文本：局面概要、座位、本墩出牌、手牌，以及轮到自己时的操作提示
+func renderView(v *game.ViewState) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n===== 房间 %s · 第%d小局 · %s · v%d =====\n", v.RoomID, v.RoundIndex+1, phaseNames[v.Phase], v.Version)
+	fmt.Fprintf(&b, "级牌  0队 %s  1队 %s", v.Teams[0].LevelRank, v.Teams[1].LevelRank)
+	if v.Phase != game.PhaseLobby {
+		fmt.Fprintf(&b, "   主：%s   打家得分 %d", trumpText(v), v.Points)
+		if v.Phase == game.PhasePlayTrick {
+			fmt.Fprintf(&b, "   第%d墩", v.TrickIndex+1)
+		}
+	}
+	b.WriteString("\n")
+
+	for i, s := range v.Seats {
+		fmt.Fprintf(&b, "  %s座位%d %-16s", marker(v, i), i, seatName(s))
+		if s.UID != "" {
+			fmt.Fprintf(&b, " %d队", s.Team)
+			if v.Phase == game.PhaseLobby {
+				if s.Ready {
+					b.WriteString(" 已准备")
+				}
+			} else {
+				fmt.Fprintf(&b, " %2d张", s.HandCount)
+			}
+			if !s.Online {
+				b.WriteString(" 离线")
+			}
+			if s.UID == v.Host {
+				b.WriteString(" 房主")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if v.Phase == game.PhasePlayTrick || v.Phase == game.PhaseRoundSettle || v.Phase == game.PhaseGameOver {
+		renderTrick(&b, v)
+	}
+	renderHand(&b, v)
+	renderResult(&b, v)
+
+	if v.UndoVote != nil {
+		fmt.Fprintf(&b, "悔棋表决：%s 申请撤回 %s 的 %s（已同意 %s）—— yes / no\n",
+			v.UndoVote.Proposer, v.UndoVote.TargetUID, v.UndoVote.Action, strings.Join(v.UndoVote.Accepted, "、"))
+	}
+	if v.Deadline > 0 {
+		if left := time.Until(time.UnixMilli(v.Deadline)); left > 0 {
+			fmt.Fprintf(&b, "剩余时间 %ds\n", int(left.Seconds()))
+		}
+	}
+	if hint := turnHint(v); hint != "" {
+		fmt.Fprintf(&b, "> %s\n", hint)
+	}
+	return b.String()
+}
+
+func trumpText(v *game.ViewState) string {
+	t := v.Trump
+	if v.Phase == game.PhaseDealing || v.Phase == game.PhaseCallTrump {
+		return "未定"
+	}
+	if t.CallerSeat < 0 && !t.HasTrumpSuit {
+		return fmt.Sprintf("硬主 打%s", t.LevelRank)
+	}
+	suit := "无花色"
+	if t.HasTrumpSuit {
+		suit = suitNames[t.Suit]
+	}
+	s := fmt.Sprintf("%s 打%s", suit, t.LevelRank)
+	if t.CallerSeat >= 0 {
+		s += fmt.Sprintf("（座位%d定", t.CallerSeat)
+		if t.Locked {
+			s += "，已锁主"
+		}
+		s += "）"
+	}
+	return s
+}
+
+func seatName(s game.SeatView) string {
+	switch {
+	case s.UID != "":
+		return s.UID
+	case s.Locked:
+		return "（已锁定）"
+	}
+	return "（空）"
+}
+
+// marker 座位前的标记：* 自己，> 轮到该座位
+func marker(v *game.ViewState, seat int) string {
+	m := []byte("  ")
+	if seat == v.MySeat {
+		m[0] = '*'
+	}
+	if seat == turnSeat(v) {
+		m[1] = '>'
+	}
+	return string(m)
+}
+
+// turnSeat 当前需要操作的座位，-1 表示没有明确轮次
+func turnSeat(v *game.ViewState) int {
+	switch v.Phase {
+	case game.PhaseCallTrump:
+		if v.CallMode == game.CallModeOrdered {
+			return v.CallTurnSeat
+		}
+	case game.PhaseBottom:
+		return v.BottomOwnerSeat
+	case game.PhasePlayTrick:
+		return v.Trick.TurnSeat
+	case game.PhaseRoundSettle:
+		return v.NextStarterSeat
+	}
+	return -1
+}
+
+// renderTrick 按出牌顺序列出本墩；本墩还没人出牌时显示上一墩（本墩先手即上一墩赢家）
+func renderTrick(b *strings.Builder, v *game.ViewState) {
+	plays, title := v.Trick.Plays, "本墩"
+	empty := true
+	for _, p := range plays {
+		if p != nil && p.Seat >= 0 {
+			empty = false
+		}
+	}
+	if empty {
+		plays, title = v.Trick.LastPlays, fmt.Sprintf("上一墩（座位%d赢）", v.Trick.LeaderSeat)
+	}
+	lines := make([]string, 0, 4)
+	for i := 0; i < 4; i++ {
+		seat := (v.Trick.LeaderSeat + i) % 4
+		p := plays[seat]
+		if p == nil || p.Seat < 0 {
+			continue
+		}
+		line := fmt.Sprintf("  座位%d  %s", seat, faces(p.Cards))
+		if p.Info != "" {
+			line += "  " + p.Info
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	if !empty && v.Trick.Throw != nil && v.Trick.Throw.IsThrow && !v.Trick.Throw.ThrowOK {
+		title += fmt.Sprintf("（甩牌失败，原计划 %s）", faces(v.Trick.Throw.IntentMove.Cards))
+	}
+	b.WriteString(title + "\n")
+	b.WriteString(strings.Join(lines, "\n") + "\n")
+}
+
+func renderHand(b *strings.Builder, v *game.ViewState) {
+	if len(v.MyHand) == 0 && len(v.MyBottom) == 0 {
+		return
+	}
+	title := "手牌"
+	if v.Spectating {
+		title = fmt.Sprintf("座位%d 的手牌", v.FollowSeat)
+	}
+	n := 0
+	for _, g := range v.MyHand {
+		n += len(g)
+	}
+	fmt.Fprintf(b, "%s（%d张）\n", title, n)
+	// MyHand 已按牌域分组（主牌、黑桃、红桃、梅花、方块）
+	for _, g := range v.MyHand {
+		fmt.Fprintf(b, "  %s  %s\n", g[0].SuitClass, faces(g))
+	}
+	if len(v.MyBottom) > 0 {
+		fmt.Fprintf(b, "  底牌  %s\n", faces(v.MyBottom))
+	}
+}
+
+func renderResult(b *strings.Builder, v *game.ViewState) {
+	if v.BottomRevealed && len(v.BottomReveal) > 0 {
+		fmt.Fprintf(b, "底牌 %s：%d分 ×%d = %d\n", faces(v.BottomReveal), v.BottomPoints, v.BottomMul, v.BottomAward)
+	}
+	switch v.Phase {
+	case game.PhaseRoundSettle:
+		fmt.Fprintf(b, "结算：打家得分 %d，%s（坐家 %+d，打家 %+d）\n", v.RoundPointsFinal, v.RoundResultLabel, v.CallerDelta, v.DefenderDelta)
+	case game.PhaseGameOver:
+		if m := v.Match; m != nil {
+			fmt.Fprintf(b, "整局结束：%d队获胜，共%d小局，最终级牌 %s / %s\n", m.WinnerTeam, m.Rounds, m.FinalLevels[0], m.FinalLevels[1])
+		}
+	}
+}
+
+// turnHint 轮到自己时提示可用的命令
+func turnHint(v *game.ViewState) string {
+	if v.Spectating || v.MySeat < 0 {
+		if v.Phase == game.PhaseLobby && !v.Spectating {
+			return "sit <座位> 入座"
+		}
+		return ""
+	}
+	switch v.Phase {
+	case game.PhaseLobby:
+		if !v.Seats[v.MySeat].Ready {
+			return "ready 准备"
+		}
+		if v.Host == v.Seats[v.MySeat].UID {
+			return "start 开始（start force 可带空位开始）"
+		}
+	case game.PhaseCallTrump:
+		if !v.CallPassedSeats[v.MySeat] && (v.CallMode == game.CallModeRace || v.CallTurnSeat == v.MySeat) {
+			return "call <王> <级牌...> 定主，或 pass"
+		}
+	case game.PhaseBottom:
+		if v.BottomOwnerSeat == v.MySeat {
+			return "bury <8张牌> 扣底"
+		}
+	case game.PhaseTrumpFight:
+		if !v.FightPassedSeats[v.MySeat] {
+			return "change <王> <级牌> <级牌> 改主，attack <王> <王> 攻主，或 pass"
+		}
+	case game.PhasePlayTrick:
+		if v.Trick.TurnSeat == v.MySeat {
+			return "轮到你出牌：play <牌...>"
+		}
+	case game.PhaseRoundSettle:
+		if v.NextStarterSeat == v.MySeat {
+			return "next 开始下一小局"
+		}
+	case game.PhaseGameOver:
+		return "rematch 再来一局"
+	}
+	return ""
+}
+
+func faces(cards []rules.Card) string {
+	out := make([]string, len(cards))
+	for i, c := range cards {
+		out[i] = game.CardFace(c)
+	}
+	return strings.Join(out, " ")
+}
+
+// renderChat 聊天一行：[频道] 发送者: 内容
+func renderChat(m game.ChatMsg) string {
+	who := m.UID
+	if m.Seat >= 0 {
+		who = fmt.Sprintf("%s(座位%d)", m.UID, m.Seat)
+	}
+	text := m.Text
+	if text == "" {
+		text = game.Emotes[m.Emote]
+	}
+	channel := ""
+	if m.Audience != "" && m.Audience != game.AudienceAll {
+		channel = fmt.Sprintf("[%s] ", m.Audience)
+	}
+	return fmt.Sprintf("💬 %s%s: %s", channel, who, text)
+}
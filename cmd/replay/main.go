@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/game/rules"
+	"upgrade-lan/internal/replay"
+	"upgrade-lan/internal/room"
+)
+
+// replay 离线重放：读取房间 replay log，逐条喂回 game.Reduce 重建 GameState
+//
+//	go run ./cmd/replay -log replay/room1.jsonl             # 打印每一步的完整状态 diff
+//	go run ./cmd/replay -log replay/room1.jsonl -until 42   # 重放到 Version>=42 为止
+//	go run ./cmd/replay -log replay/room1.jsonl -dump       # 结束后打印完整状态
+func main() {
+	path := flag.String("log", "", "replay log 文件路径（.jsonl）")
+	until := flag.Int64("until", -1, "重放到该 Version 为止（-1 表示全部）")
+	dump := flag.Bool("dump", false, "结束后打印完整状态")
+	quiet := flag.Bool("quiet", false, "不打印每一步的 diff")
+	flag.Parse()
+
+	if *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	entries, err := replay.ReadFile(*path)
+	if err != nil {
+		log.Fatalf("读取 replay log 失败: %v", err)
+	}
+
+	var st game.GameState
+	for i, e := range entries {
+		prev := st
+		switch e.Type {
+		case replay.TypeOpen:
+			st = game.NewGameState(e.RoomID)
+		case replay.TypeRestore, replay.TypeUndo:
+			restored, uerr := game.UnmarshalFull(e.Payload)
+			if uerr != nil {
+				log.Fatalf("#%d 恢复 state 失败: %v", i, uerr)
+			}
+			st = restored
+		case replay.TypeJoin:
+			st = game.MarkOnline(st, e.UID)
+		case replay.TypeLeave:
+			st = game.MarkOffline(st, e.UID)
+		default:
+			evType, payload, perr := room.ParseClientEvent(string(e.Type), e.Payload)
+			if perr != nil {
+				log.Fatalf("#%d 解析事件失败: %v", i, perr)
+			}
+			seeds := game.NewFixedSeedSource()
+			if e.Seed != nil {
+				seeds = game.NewFixedSeedSource(*e.Seed)
+			}
+			res, rerr := game.Reduce(st, seeds, e.UID, evType, payload)
+			if rerr != nil {
+				log.Fatalf("#%d %s %s 重放被拒绝: %v", i, e.UID, e.Type, rerr)
+			}
+			st = res.State
+		}
+		if st.Version != e.Version {
+			fmt.Printf("!! #%d Version 不一致：日志=%d 重放=%d\n", i, e.Version, st.Version)
+		}
+
+		if !*quiet {
+			fmt.Printf("#%d v=%d %s uid=%s\n", i, st.Version, e.Type, e.UID)
+			for _, line := range diff(flatten(prev), flatten(st)) {
+				fmt.Println("   ", line)
+			}
+		}
+		if *until >= 0 && st.Version >= *until {
+			break
+		}
+	}
+
+	if *dump {
+		lines := flatten(st)
+		keys := make([]string, 0, len(lines))
+		for k := range lines {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("%s = %s\n", k, lines[k])
+		}
+	}
+}
+
+// flatten 把完整 GameState（含 json:"-" 私有字段）展开为 路径 -> 值
+func flatten(st game.GameState) map[string]string {
+	out := make(map[string]string)
+	walk("", reflect.ValueOf(st), out)
+	return out
+}
+
+var cardType = reflect.TypeOf(rules.Card{})
+
+func walk(path string, v reflect.Value, out map[string]string) {
+	if v.Type() == cardType {
+		c := v.Interface().(rules.Card)
+		out[path] = fmt.Sprintf("%s%s#%d", c.Suit, c.Rank, c.ID)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			out[path] = "nil"
+			return
+		}
+		walk(path, v.Elem(), out)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := f.Name
+			if f.Anonymous {
+				walk(path, v.Field(i), out)
+				continue
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			walk(name, v.Field(i), out)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem() == cardType {
+			// 牌组整体展示，diff 更紧凑
+			parts := make([]string, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				c := v.Index(i).Interface().(rules.Card)
+				parts = append(parts, fmt.Sprintf("%s%s#%d", c.Suit, c.Rank, c.ID))
+			}
+			out[path] = "[" + strings.Join(parts, " ") + "]"
+			return
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			out[path] = "nil"
+			return
+		}
+		out[path+".len"] = fmt.Sprint(v.Len())
+		for i := 0; i < v.Len(); i++ {
+			walk(fmt.Sprintf("%s[%d]", path, i), v.Index(i), out)
+		}
+	default:
+		out[path] = fmt.Sprintf("%v", v.Interface())
+	}
+}
+
+// diff 返回按路径排序的变化行
+func diff(a, b map[string]string) []string {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var out []string
+	for _, k := range sorted {
+		av, aok := a[k]
+		bv, bok := b[k]
+		switch {
+		case !aok:
+			out = append(out, fmt.Sprintf("+ %s = %s", k, bv))
+		case !bok:
+			out = append(out, fmt.Sprintf("- %s = %s", k, av))
+		case av != bv:
+			out = append(out, fmt.Sprintf("~ %s: %s -> %s", k, av, bv))
+		}
+	}
+	return out
+}
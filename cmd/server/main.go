@@ -1,24 +1,118 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/game/rules"
+	"upgrade-lan/internal/metrics"
 	"upgrade-lan/internal/room"
+	"upgrade-lan/internal/session"
+	"upgrade-lan/internal/stats"
 	"upgrade-lan/internal/ws"
 )
 
 func main() {
+	seeds := flag.String("seeds", "", "固定发牌种子序列，逗号分隔，每项为 64 位十六进制或十进制整数（复现用，留空则使用安全随机源）")
+	replayDir := flag.String("replay-dir", "replay", "replay log 目录，留空则不记录")
+	dataDir := flag.String("data-dir", "data", "房间状态落盘目录，留空则不落盘（重启后房间丢失）")
+	timerCall := flag.Duration("timer-call", 0, "新房间默认的定主阶段时限（如 30s），0 表示不计时；房主可在大厅修改")
+	timerBottom := flag.Duration("timer-bottom", 0, "新房间默认的扣底阶段时限")
+	timerFight := flag.Duration("timer-fight", 0, "新房间默认的改主/攻主阶段时限")
+	timerPlay := flag.Duration("timer-play", 0, "新房间默认的出牌时限")
+	timeBank := flag.Duration("time-bank", 0, "新房间默认的棋钟：每小局每人额外可透支的时间")
+	roomIdle := flag.Duration("room-idle", 30*time.Minute, "无连接超过该时长的房间被回收（对局进行中的房间保留落盘数据，有人连接时重新加载），0 表示不回收")
+	inviteTTL := flag.Duration("invite-ttl", 2*time.Hour, "私密房间一次性邀请的有效期")
+	sessionKey := flag.String("session-key", "", "会话 token 签名密钥；留空则使用数据目录下的 session.key（不存在时自动生成）")
+	sessionTTL := flag.Duration("session-ttl", 30*24*time.Hour, "会话 token 有效期")
+	openLAN := flag.Bool("open-lan", false, "开放局域网模式：允许不带 token、直接用 ?uid= 连接（任何人都能冒充他人，仅限可信网络）")
+	specDelay := flag.Int("spectator-delay", 0, "观战延迟（按 Version 计）")
+	followDelay := flag.Int("follow-delay", 0, "跟随座位观战的延迟（按 Version 计），0 表示不允许跟随")
+	flag.Parse()
+
+	opts := room.Options{
+		ReplayDir: *replayDir,
+		DataDir:   *dataDir,
+		Timers: game.TurnTimers{
+			CallTrump:  int(timerCall.Seconds()),
+			Bottom:     int(timerBottom.Seconds()),
+			TrumpFight: int(timerFight.Seconds()),
+			PlayTrick:  int(timerPlay.Seconds()),
+			TimeBank:   int(timeBank.Seconds()),
+		},
+		IdleTimeout:    *roomIdle,
+		InviteTTL:      *inviteTTL,
+		SpectatorDelay: *specDelay,
+		FollowDelay:    *followDelay,
+	}
+	if err := opts.Timers.Validate(); err != nil {
+		log.Fatalf("invalid timers: %s", err.Info)
+	}
+	if *seeds != "" {
+		for _, s := range strings.Split(*seeds, ",") {
+			v, err := rules.ParseSeed(strings.TrimSpace(s))
+			if err != nil {
+				log.Fatalf("invalid seed %q: %v", s, err)
+			}
+			opts.DealSeeds = append(opts.DealSeeds, v)
+		}
+	}
+
+	var key []byte
+	switch {
+	case *sessionKey != "":
+		key = []byte(*sessionKey)
+	case *dataDir != "":
+		k, err := session.LoadOrCreateKey(filepath.Join(*dataDir, "session.key"))
+		if err != nil {
+			log.Fatalf("load session key: %v", err)
+		}
+		key = k
+	default:
+		key = session.RandomKey() // 不落盘：重启后旧 token 全部失效
+	}
+	auth := &session.Auth{Signer: session.NewSigner(key, *sessionTTL), OpenLAN: *openLAN}
+	opts.Authenticate = auth.Authenticate // POST /rooms 的创建者成为房主
+
+	// 玩家统计：落盘到数据目录下 stats/，未开启数据目录时只在内存中统计
+	statsDir := ""
+	if *dataDir != "" {
+		statsDir = filepath.Join(*dataDir, "stats")
+	}
+	book := stats.Open(statsDir)
+	go book.FlushLoop(10 * time.Second)
+	opts.Stats = book
+
 	hub := ws.NewHub()
 	go hub.Run()
 
-	rm := room.NewManager() // room 不再需要 hub/ws
+	rm := room.NewManager(opts) // room 不再需要 hub/ws
 
 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		// ws 只依赖一个 Router 接口（rm 实现它）
-		ws.ServeWS(hub, rm, w, r)
+		ws.ServeWS(hub, rm, auth, w, r)
 	})
 
+	// 签发会话 token
+	http.HandleFunc("/session", auth.ServeSession)
+
+	// 房间目录（GET）与创建房间（POST）
+	http.HandleFunc("/rooms", rm.ServeRooms)
+
+	// 牌谱下载：GET ?room=&round=
+	http.HandleFunc("/hands", rm.ServeHands)
+
+	// 玩家统计：GET ?uid= 单人详情，?sort=&limit= 排行榜
+	http.HandleFunc("/stats", book.ServeStats)
+
+	// 运行指标（Prometheus 文本格式）
+	http.HandleFunc("/metrics", metrics.ServeMetrics)
+
 	http.Handle("/", http.FileServer(http.Dir("./web")))
 
 	addr := ":8080"
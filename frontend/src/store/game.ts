@@ -1,10 +1,11 @@
 import { defineStore } from 'pinia'
 import { wsService } from '../services/ws'
-import type { ServerMessage } from '../types/protocol'
+import type { ServerMessage, ChatMsg, ChatAudience } from '../types/protocol'
+import { applyPatch } from '../utils/patch'
 
 type MessageItem = {
     id: number
-    level: 'error' | 'notice'
+    level: 'error' | 'notice' | 'chat'
     text: string
 }
 
@@ -32,6 +33,8 @@ export const useGameStore = defineStore('game', {
             switch (msg.type) {
                 case 'hello':
                     this.uid = msg.uid
+                    // 协商差量快照（服务端会先回一次全量）
+                    wsService.send('room.sync', { delta: true })
                     break
 
                 case 'snapshot':
@@ -39,14 +42,35 @@ export const useGameStore = defineStore('game', {
                     this.view = msg.state
                     break
 
+                case 'delta':
+                    // 版本对不上说明丢了消息：请求全量重新同步
+                    if (!this.view || this.view.version !== msg.baseVersion) {
+                        wsService.send('room.sync', { delta: true })
+                        break
+                    }
+                    this.view = applyPatch(structuredClone(this.view), msg.ops)
+                    break
+
+                case 'chat':
+                    this.pushChat(msg)
+                    break
+
+                case 'chat_history':
+                    msg.messages.forEach((m) => this.pushChat(m))
+                    break
+
                 case 'error':
-                    this.pushMessage('error', msg.message)
+                    this.pushMessage('error', msg.info || msg.message)
                     break
 
                 case 'notice':
                     this.pushMessage('notice', msg.message)
                     break
 
+                case 'event':
+                    // 领域事件供动效使用；状态仍以 snapshot/delta 为准
+                    break
+
                 default:
                     console.warn('[store] unknown message', msg)
             }
@@ -56,7 +80,21 @@ export const useGameStore = defineStore('game', {
             wsService.send(type, payload)
         },
 
-        pushMessage(level: 'error' | 'notice', text: string) {
+        // 聊天/表情：audience 为空时服务端按身份默认（玩家 all，观战者 spectators）
+        sendChat(text: string, audience?: ChatAudience) {
+            wsService.send('room.chat', { text, audience })
+        },
+
+        sendEmote(emote: string, audience?: ChatAudience) {
+            wsService.send('room.emote', { emote, audience })
+        },
+
+        pushChat(m: ChatMsg) {
+            const channel = m.audience === 'seated' ? '[玩家] ' : m.audience === 'spectators' ? '[观战] ' : ''
+            this.pushMessage('chat', `${channel}${m.uid}：${m.text}`)
+        },
+
+        pushMessage(level: 'error' | 'notice' | 'chat', text: string) {
             this.messages.push({
                 id: ++this._msgId,
                 level,
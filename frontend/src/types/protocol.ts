@@ -1,3 +1,5 @@
+import type { PatchOp } from '../utils/patch'
+
 // ===== Server -> Client =====
 
 export type HelloMsg = {
@@ -10,25 +12,101 @@ export type SnapshotMsg<T = any> = {
     state: T
 }
 
+// 差量快照：在 room.sync {delta: true} 之后下发，作用于 version === baseVersion 的本地 view
+export type DeltaMsg = {
+    type: 'delta'
+    baseVersion: number
+    version: number
+    ops: PatchOp[]
+}
+
+export type ChatAudience = 'all' | 'seated' | 'spectators'
+
+export type ChatMsg = {
+    type: 'chat'
+    seq: number
+    uid: string
+    seat: number
+    kind: 'text' | 'emote'
+    text: string
+    emote?: string
+    audience: ChatAudience
+    time: number
+}
+
+// 加入房间时补发的最近聊天
+export type ChatHistoryMsg = {
+    type: 'chat_history'
+    messages: ChatMsg[]
+}
+
+// 错误分类：reject 业务拒绝 / invalid 非法请求 / system 系统错误
+export type ErrorCategory = 'reject' | 'invalid' | 'system'
+
 export type ErrorMsg = {
     type: 'error'
+    code: string
+    category: ErrorCategory
     message: string
+    info?: string // 本次出错的具体说明，优先于 message 展示
+    event?: string // 出错的事件类型
+    reqId?: string
 }
 
+// 结构化通知：code 稳定可机读（如 'trick.defender'），params 为座位/分数/花色等参数，message 为服务端按握手语言渲染的文案
 export type NoticeMsg = {
     type: 'notice'
+    code: string
+    params?: Record<string, unknown>
     message: string
 }
 
+// 领域事件：kind 如 'trick_won' / 'throw_failed' / 'bottom_dug'，event 为对应的结构化字段；version 为产生它的 state 版本
+export type EventKind =
+    | 'round_dealt'
+    | 'trump_called'
+    | 'no_trump'
+    | 'trump_changed'
+    | 'trump_attacked'
+    | 'bottom_buried'
+    | 'play_started'
+    | 'cards_played'
+    | 'throw_failed'
+    | 'trick_won'
+    | 'bottom_dug'
+    | 'round_settled'
+    | 'match_over'
+
+export type EventMsg = {
+    type: 'event'
+    version: number
+    kind: EventKind
+    event: Record<string, unknown>
+}
+
+export type AckMsg = {
+    type: 'ack'
+    reqId: string
+    ok: boolean
+    code?: string
+    version: number
+}
+
 export type ServerMessage =
     | HelloMsg
     | SnapshotMsg
+    | DeltaMsg
+    | ChatMsg
+    | ChatHistoryMsg
     | ErrorMsg
     | NoticeMsg
+    | EventMsg
+    | AckMsg
 
 // ===== Client -> Server =====
 
 export type ClientEvent<T = any> = {
     type: string
+    reqId?: string
     payload: T
 }
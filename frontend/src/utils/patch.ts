@@ -0,0 +1,37 @@
+// JSON-Patch（add / remove / replace），用于应用服务端的 delta 消息
+
+export type PatchOp = {
+    op: 'add' | 'remove' | 'replace'
+    path: string
+    value?: any
+}
+
+function unescape(s: string): string {
+    return s.replace(/~1/g, '/').replace(/~0/g, '~')
+}
+
+// applyPatch 原地修改 doc 并返回新的根（根路径 "" 会整体替换）
+export function applyPatch(doc: any, ops: PatchOp[]): any {
+    for (const op of ops) {
+        if (op.path === '') {
+            doc = op.value
+            continue
+        }
+        const parts = op.path.slice(1).split('/').map(unescape)
+        const key = parts.pop()!
+        let parent = doc
+        for (const p of parts) {
+            parent = Array.isArray(parent) ? parent[Number(p)] : parent[p]
+        }
+        if (Array.isArray(parent)) {
+            const i = Number(key)
+            if (op.op === 'add') parent.splice(i, 0, op.value)
+            else if (op.op === 'remove') parent.splice(i, 1)
+            else parent[i] = op.value
+        } else {
+            if (op.op === 'remove') delete parent[key]
+            else parent[key] = op.value
+        }
+    }
+    return doc
+}
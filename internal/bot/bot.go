@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/game/rules"
+)
+
+// Bot 服务端机器人：只能看到与真人相同的 ViewState，
+// 返回的事件由 room 按真人同样的路径（ParseClientEvent -> game.Reduce）处理，因此无法作弊。
+// ok=false 表示当前无事可做（没轮到自己等）。
+type Bot interface {
+	Decide(v game.ViewState) (typ game.ClientEventType, payload any, ok bool)
+}
+
+// Simple 最朴素的机器人：能定主就定主、不改不攻、扣最小的副牌、出最小的合法牌
+type Simple struct{}
+
+func NewSimple() *Simple { return &Simple{} }
+
+func (b *Simple) Decide(v game.ViewState) (game.ClientEventType, any, bool) {
+	me := v.MySeat
+	if me < 0 || me > 3 {
+		return "", nil, false
+	}
+	hand := flattenHand(v.MyHand)
+
+	switch v.Phase {
+	case game.PhaseLobby:
+		if !v.Seats[me].Ready {
+			return game.EvReady, struct{}{}, true
+		}
+
+	case game.PhaseCallTrump:
+		if v.CallPassedSeats[me] {
+			return "", nil, false
+		}
+		if v.CallMode == game.CallModeOrdered && v.CallTurnSeat != me {
+			return "", nil, false
+		}
+		if v.CallMode == game.CallModeRace && v.StarterSeat >= 0 {
+			return "", nil, false
+		}
+		level := v.Teams[v.Seats[me].Team].LevelRank
+		if p, ok := pickCallTrump(hand, level); ok {
+			return game.EvCallTrump, p, true
+		}
+		return game.EvCallPass, struct{}{}, true
+
+	case game.PhaseBottom:
+		if v.BottomOwnerSeat != me {
+			return "", nil, false
+		}
+		return game.EvPutBottom, game.PutBottomPayload{DiscardIDs: ids(rules.CheapestCards(hand, v.Trump.Trump, 8))}, true
+
+	case game.PhaseTrumpFight:
+		if v.BottomOwnerSeat == me || v.FightPassedSeats[me] {
+			return "", nil, false
+		}
+		return game.EvCallPass, struct{}{}, true
+
+	case game.PhasePlayTrick:
+		tr := v.Trick
+		if tr.TurnSeat != me || tr.Plays[me] != nil {
+			return "", nil, false
+		}
+		if tr.LeaderSeat == me {
+			return game.EvPlayCards, game.PlayCardsPayload{CardIDs: pickLead(hand, v.Trump.Trump)}, true
+		}
+		lead := tr.Plays[tr.LeaderSeat]
+		if lead == nil {
+			return "", nil, false
+		}
+		ids := pickFollow(hand, lead.Blocks, v.Trump.Trump)
+		return game.EvPlayCards, game.PlayCardsPayload{CardIDs: ids}, true
+
+	case game.PhaseRoundSettle:
+		if v.NextStarterSeat == me {
+			return game.EvStartNextRound, struct{}{}, true
+		}
+	}
+	return "", nil, false
+}
+
+func flattenHand(groups [][]rules.Card) []rules.Card {
+	out := make([]rules.Card, 0, 33)
+	for _, g := range groups {
+		out = append(out, g...)
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/game/rules"
+)
+
+// pickCallTrump 手里有同色王 + 本队级牌即定主，有一对级牌则锁主
+func pickCallTrump(hand []rules.Card, level rules.Rank) (game.CallTrumpPayload, bool) {
+	var jokers, levels []rules.Card
+	for _, c := range hand {
+		switch {
+		case rules.IsBigJoker(c) || rules.IsSmallJoker(c):
+			jokers = append(jokers, c)
+		case c.Rank == level:
+			levels = append(levels, c)
+		}
+	}
+	for _, j := range jokers {
+		// 优先一对级牌（锁主）
+		for i := 0; i < len(levels); i++ {
+			for k := i + 1; k < len(levels); k++ {
+				pair := []rules.Card{levels[i], levels[k]}
+				if _, _, err := rules.ValidateCallTrump(level, j, pair); err == nil {
+					return game.CallTrumpPayload{JokerID: j.ID, LevelIDs: []int{levels[i].ID, levels[k].ID}}, true
+				}
+			}
+		}
+		for _, lc := range levels {
+			if _, _, err := rules.ValidateCallTrump(level, j, []rules.Card{lc}); err == nil {
+				return game.CallTrumpPayload{JokerID: j.ID, LevelIDs: []int{lc.ID}}, true
+			}
+		}
+	}
+	return game.CallTrumpPayload{}, false
+}
+
+// pickLead 先手：在最长的副牌花色里出最大的对子，否则出最大的单张；只剩主牌时出最小的主
+func pickLead(hand []rules.Card, t rules.Trump) []int {
+	counts := make(map[rules.SuitClass]int)
+	for _, c := range hand {
+		if c.SuitClass != rules.SCTrump {
+			counts[c.SuitClass]++
+		}
+	}
+	best := rules.SCTrump
+	for _, sc := range []rules.SuitClass{rules.SCH, rules.SCS, rules.SCD, rules.SCC} {
+		if counts[sc] > counts[best] || (best == rules.SCTrump && counts[sc] > 0) {
+			best = sc
+		}
+	}
+	if best != rules.SCTrump {
+		if pairs, err := rules.FindBlocksInHand(hand, t, best, rules.BlockPair, 0); err == nil && len(pairs) > 0 {
+			return ids(pairs[0].Cards)
+		}
+		if singles, err := rules.FindBlocksInHand(hand, t, best, rules.BlockSingle, 0); err == nil && len(singles) > 0 {
+			return ids(singles[0].Cards)
+		}
+	}
+	if singles, err := rules.FindBlocksInHand(hand, t, rules.SCTrump, rules.BlockSingle, 0); err == nil && len(singles) > 0 {
+		return ids(singles[len(singles)-1].Cards)
+	}
+	return ids(hand[:1])
+}
+
+// pickFollow 跟牌：取合法跟牌生成器给出的最小候选
+func pickFollow(hand []rules.Card, lead [][]rules.Block, t rules.Trump) []int {
+	cands, err := rules.LegalFollows(hand, lead, t, 1)
+	if err != nil || len(cands) == 0 {
+		return nil
+	}
+	return ids(cands[0].Cards)
+}
+
+func ids(cards []rules.Card) []int {
+	out := make([]int, 0, len(cards))
+	for _, c := range cards {
+		out = append(out, c.ID)
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ChatAudience 聊天频道
+type ChatAudience string
+
+const (
+	AudienceAll        ChatAudience = "all"        // 房间内所有人
+	AudienceSeated     ChatAudience = "seated"     // 仅入座玩家
+	AudienceSpectators ChatAudience = "spectators" // 仅观战者（观战者只能在此频道发言，避免向玩家泄露手牌）
+)
+
+const MaxChatRunes = 120
+
+// Emotes 表情与快捷短语，key 为客户端发送的 emote id
+var Emotes = map[string]string{
+	"thumbs_up": "👍",
+	"laugh":     "😂",
+	"cry":       "😭",
+	"angry":     "😠",
+	"shock":     "😱",
+	"flower":    "🌹",
+	"hurry":     "快点吧，等到花儿都谢了",
+	"nice":      "好牌！",
+	"sorry":     "不好意思，手滑了",
+	"partner":   "队友给力！",
+	"again":     "再来一局",
+	"bye":       "先走了，下次再玩",
+}
+
+type ChatPayload struct {
+	Text     string       `json:"text"`
+	Audience ChatAudience `json:"audience"` // 可空，默认 all
+}
+
+type EmotePayload struct {
+	Emote    string       `json:"emote"`
+	Audience ChatAudience `json:"audience"`
+}
+
+func validateAudience(a ChatAudience) *AppError {
+	switch a {
+	case "", AudienceAll, AudienceSeated, AudienceSpectators:
+		return nil
+	}
+	return ErrInvalidPayload.WithInfof("未知的聊天频道 %s", a)
+}
+
+func (p ChatPayload) Validate() *AppError {
+	text := strings.TrimSpace(p.Text)
+	if text == "" {
+		return ErrInvalidPayload.WithInfo("聊天内容不能为空")
+	}
+	if len([]rune(text)) > MaxChatRunes {
+		return ErrInvalidPayload.WithInfof("聊天内容最长%d个字", MaxChatRunes)
+	}
+	for _, r := range text {
+		if unicode.IsControl(r) {
+			return ErrInvalidPayload.WithInfo("聊天内容不能包含控制字符")
+		}
+	}
+	return validateAudience(p.Audience)
+}
+
+func (p EmotePayload) Validate() *AppError {
+	if _, ok := Emotes[p.Emote]; !ok {
+		return ErrInvalidPayload.WithInfof("未知的表情 %s", p.Emote)
+	}
+	return validateAudience(p.Audience)
+}
+
+// ChatMsg 一条聊天/表情
+// - Kind：text 或 emote；emote 时 Emote 为 id，Text 为对应的展示文案
+type ChatMsg struct {
+	Type     string       `json:"type"` // "chat"
+	Seq      int64        `json:"seq"`  // 房间内递增
+	UID      string       `json:"uid"`
+	Seat     int          `json:"seat"` // 发送时所在座位，-1 表示未入座/观战
+	Kind     string       `json:"kind"`
+	Text     string       `json:"text"`
+	Emote    string       `json:"emote,omitempty"`
+	Audience ChatAudience `json:"audience"`
+	Time     int64        `json:"time"` // unix 毫秒
+}
+
+// ChatHistoryMsg 新连接加入时补发的最近聊天记录（已按该连接可见的频道过滤）
+type ChatHistoryMsg struct {
+	Type     string    `json:"type"` // "chat_history"
+	Messages []ChatMsg `json:"messages"`
+}
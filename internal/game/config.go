@@ -0,0 +1,213 @@
+package game
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"upgrade-lan/internal/game/rules"
+)
+
+// ScoreTier 小局结算档位：打家得分 >= MinPoints 时命中
+type ScoreTier struct {
+	MinPoints     int    `json:"minPoints"`
+	Label         string `json:"label"`
+	CallerDelta   int    `json:"callerDelta"`   // 坐家队升级
+	DefenderDelta int    `json:"defenderDelta"` // 打家队升级
+}
+
+// RoomConfig 房间规则方案：大厅阶段由房主选择（room.settings），开局后整局不变
+// - Tiers：结算档位，MinPoints 严格递减，最后一档为 0
+// - Shutout：打家 0 分时单独判定的档位（光头），为空则按 Tiers 结算
+// - SwapPoints：打家得分达到该值时下一小局由打家先定主，必须是某一档的 MinPoints
+// - Dig：末墩抠底倍数
+type RoomConfig struct {
+	Profile    string        `json:"profile"`
+	Tiers      []ScoreTier   `json:"tiers"`
+	Shutout    *ScoreTier    `json:"shutout,omitempty"`
+	SwapPoints int           `json:"swapPoints"`
+	Dig        rules.DigRule `json:"dig"`
+}
+
+const (
+	ProfileStandard    = "standard"     // 默认：200/160/120/80/40/0，抠底 ×1/×2/×4
+	ProfileTractorPow2 = "tractor_pow2" // 同默认档位，拖拉机抠底 ×2^连对数
+	ProfileLadder20    = "ladder20"     // 以 80 分为界，每 20 分一级
+)
+
+// 自定义方案的取值上限
+const (
+	maxTierCount   = 41 // 0~200 每 5 分一档
+	maxTierDelta   = 12 // 2 升到 A 为 12 级
+	maxDigMul      = 64
+	maxProfileName = 24
+	maxTierLabel   = 12
+)
+
+// RoomPresets 内置方案名（按展示顺序）
+var RoomPresets = []string{ProfileStandard, ProfileTractorPow2, ProfileLadder20}
+
+// DefaultRoomConfig 新房间（以及旧存档缺省）使用的方案
+func DefaultRoomConfig() RoomConfig {
+	cfg, _ := RoomPreset(ProfileStandard)
+	return cfg
+}
+
+// RoomPreset 按名称取内置方案，每次返回新的副本
+func RoomPreset(name string) (RoomConfig, bool) {
+	switch name {
+	case ProfileStandard:
+		return RoomConfig{
+			Profile:    ProfileStandard,
+			Tiers:      standardTiers(),
+			Shutout:    &ScoreTier{MinPoints: 0, Label: "光头", CallerDelta: 3},
+			SwapPoints: 80,
+			Dig:        rules.StandardDig,
+		}, true
+	case ProfileTractorPow2:
+		dig := rules.StandardDig
+		dig.TractorPow2 = true
+		return RoomConfig{
+			Profile:    ProfileTractorPow2,
+			Tiers:      standardTiers(),
+			Shutout:    &ScoreTier{MinPoints: 0, Label: "光头", CallerDelta: 3},
+			SwapPoints: 80,
+			Dig:        dig,
+		}, true
+	case ProfileLadder20:
+		return RoomConfig{
+			Profile:    ProfileLadder20,
+			Tiers:      ladderTiers(80, 20),
+			SwapPoints: 80,
+			Dig:        rules.StandardDig,
+		}, true
+	default:
+		return RoomConfig{}, false
+	}
+}
+
+func standardTiers() []ScoreTier {
+	return []ScoreTier{
+		{MinPoints: 200, Label: "满分", DefenderDelta: 3},
+		{MinPoints: 160, Label: "大胜", DefenderDelta: 2},
+		{MinPoints: 120, Label: "过大关", DefenderDelta: 1},
+		{MinPoints: 80, Label: "换坐"},
+		{MinPoints: 40, Label: "过小关", CallerDelta: 1},
+		{MinPoints: 0, Label: "不过小关", CallerDelta: 2},
+	}
+}
+
+// ladderTiers 以 swap 分为界，打家每多 step 分升一级，每少 step 分坐家升一级
+func ladderTiers(swap, step int) []ScoreTier {
+	var tiers []ScoreTier
+	for p := 200 / step * step; p >= 0; p -= step {
+		t := ScoreTier{MinPoints: p}
+		switch {
+		case p >= swap:
+			t.DefenderDelta = (p - swap) / step
+		default:
+			t.CallerDelta = (swap - p + step - 1) / step
+		}
+		switch {
+		case t.DefenderDelta > 0:
+			t.Label = fmt.Sprintf("打家+%d", t.DefenderDelta)
+		case t.CallerDelta > 0:
+			t.Label = fmt.Sprintf("坐家+%d", t.CallerDelta)
+		default:
+			t.Label = "换坐"
+		}
+		tiers = append(tiers, t)
+	}
+	return tiers
+}
+
+// Outcome 按打家得分取结算档位
+func (c RoomConfig) Outcome(points int) ScoreTier {
+	if points == 0 && c.Shutout != nil {
+		return *c.Shutout
+	}
+	for _, t := range c.Tiers {
+		if points >= t.MinPoints {
+			return t
+		}
+	}
+	return ScoreTier{}
+}
+
+// Validate 校验自定义方案（内置方案也必须通过）
+func (c RoomConfig) Validate() *AppError {
+	if c.Profile == "" || utf8.RuneCountInString(c.Profile) > maxProfileName {
+		return ErrRoomBadConfig.WithInfof("方案名称需为 1~%d 个字符", maxProfileName)
+	}
+	if len(c.Tiers) == 0 || len(c.Tiers) > maxTierCount {
+		return ErrRoomBadConfig.WithInfof("结算档位需为 1~%d 档", maxTierCount)
+	}
+	if c.SwapPoints <= 0 || c.SwapPoints > 200 || c.SwapPoints%5 != 0 {
+		return ErrRoomBadConfig.WithInfo("换坐分数需为 5~200 之间 5 的倍数")
+	}
+	swapFound := false
+	for i, t := range c.Tiers {
+		if t.MinPoints < 0 || t.MinPoints > 200 || t.MinPoints%5 != 0 {
+			return ErrRoomBadConfig.WithInfof("第%d档分数需为 0~200 之间 5 的倍数", i+1)
+		}
+		if i > 0 && t.MinPoints >= c.Tiers[i-1].MinPoints {
+			return ErrRoomBadConfig.WithInfo("结算档位分数需从高到低严格递减")
+		}
+		if err := validateTier(t, fmt.Sprintf("第%d档", i+1)); err != nil {
+			return err
+		}
+		if t.MinPoints >= c.SwapPoints && t.CallerDelta > 0 {
+			return ErrRoomBadConfig.WithInfof("第%d档达到换坐分数，坐家不能升级", i+1)
+		}
+		if t.MinPoints < c.SwapPoints && t.DefenderDelta > 0 {
+			return ErrRoomBadConfig.WithInfof("第%d档未达换坐分数，打家不能升级", i+1)
+		}
+		swapFound = swapFound || t.MinPoints == c.SwapPoints
+	}
+	if c.Tiers[len(c.Tiers)-1].MinPoints != 0 {
+		return ErrRoomBadConfig.WithInfo("最后一档分数必须为 0")
+	}
+	if !swapFound {
+		return ErrRoomBadConfig.WithInfo("换坐分数必须是某一档的起始分数")
+	}
+	if s := c.Shutout; s != nil {
+		if s.MinPoints != 0 || s.DefenderDelta != 0 {
+			return ErrRoomBadConfig.WithInfo("光头档只能是 0 分且只能坐家升级")
+		}
+		if err := validateTier(*s, "光头档"); err != nil {
+			return err
+		}
+	}
+	d := c.Dig
+	if d.Single < 1 || d.Single > maxDigMul || d.Pair < 1 || d.Pair > maxDigMul {
+		return ErrRoomBadConfig.WithInfof("抠底倍数需为 1~%d", maxDigMul)
+	}
+	if !d.TractorPow2 && (d.Tractor < 1 || d.Tractor > maxDigMul) {
+		return ErrRoomBadConfig.WithInfof("抠底倍数需为 1~%d", maxDigMul)
+	}
+	return nil
+}
+
+// validateTier name 仅用于提示
+func validateTier(t ScoreTier, name string) *AppError {
+	if t.Label == "" || utf8.RuneCountInString(t.Label) > maxTierLabel {
+		return ErrRoomBadConfig.WithInfof("%s名称需为 1~%d 个字符", name, maxTierLabel)
+	}
+	if t.CallerDelta < 0 || t.CallerDelta > maxTierDelta || t.DefenderDelta < 0 || t.DefenderDelta > maxTierDelta {
+		return ErrRoomBadConfig.WithInfof("%s升级数需为 0~%d", name, maxTierDelta)
+	}
+	if t.CallerDelta > 0 && t.DefenderDelta > 0 {
+		return ErrRoomBadConfig.WithInfof("%s不能双方同时升级", name)
+	}
+	return nil
+}
+
+// clone 档位切片不与原方案共享
+func (c RoomConfig) clone() RoomConfig {
+	cp := c
+	cp.Tiers = append([]ScoreTier(nil), c.Tiers...)
+	if c.Shutout != nil {
+		s := *c.Shutout
+		cp.Shutout = &s
+	}
+	return cp
+}
@@ -0,0 +1,170 @@
+package game
+
+import "upgrade-lan/internal/game/rules"
+
+// DomainEvent Reduce 产生的领域事件：客户端不必对比前后两份快照就能知道发生了什么（赢墩、甩牌失败、改主…）
+// 事件只包含公开信息（亮出的牌、打出的牌、末墩公开的底牌），可以原样广播给所有连接
+type DomainEvent interface {
+	EventKind() EventKind
+}
+
+type EventKind string
+
+const (
+	EkRoundDealt    EventKind = "round_dealt"
+	EkTrumpCalled   EventKind = "trump_called"
+	EkNoTrump       EventKind = "no_trump"
+	EkTrumpChanged  EventKind = "trump_changed"
+	EkTrumpAttacked EventKind = "trump_attacked"
+	EkBottomBuried  EventKind = "bottom_buried"
+	EkPlayStarted   EventKind = "play_started"
+	EkCardsPlayed   EventKind = "cards_played"
+	EkThrowFailed   EventKind = "throw_failed"
+	EkTrickWon      EventKind = "trick_won"
+	EkBottomDug     EventKind = "bottom_dug"
+	EkRoundSettled  EventKind = "round_settled"
+	EkMatchOver     EventKind = "match_over"
+)
+
+// RoundDealt 发牌完成，进入定主；StarterSeat 为 -1 表示抢定主
+type RoundDealt struct {
+	Round       int           `json:"round"`
+	CallMode    CallMode      `json:"callMode"`
+	StarterSeat int           `json:"starterSeat"`
+	Levels      [2]rules.Rank `json:"levels"`
+}
+
+// TrumpCalled 定主成功
+type TrumpCalled struct {
+	Seat   int          `json:"seat"`
+	Suit   rules.Suit   `json:"suit"`
+	Rank   rules.Rank   `json:"rank"`
+	Locked bool         `json:"locked"`
+	Cards  []rules.Card `json:"cards"`
+}
+
+// NoTrump 四人都不定主，本小局硬主，直接进入出牌
+type NoTrump struct {
+	Rank       rules.Rank `json:"rank"`
+	LeaderSeat int        `json:"leaderSeat"`
+}
+
+// TrumpChanged 改主成功（只改主花色）
+type TrumpChanged struct {
+	Seat  int          `json:"seat"`
+	Suit  rules.Suit   `json:"suit"`
+	Cards []rules.Card `json:"cards"`
+}
+
+// TrumpAttacked 攻主成功，本小局硬主
+type TrumpAttacked struct {
+	Seat  int          `json:"seat"`
+	Cards []rules.Card `json:"cards"`
+}
+
+// BottomBuried 扣底完成（底牌牌面不公开）
+type BottomBuried struct {
+	Seat  int `json:"seat"`
+	Count int `json:"count"`
+}
+
+// PlayStarted 改主/攻主窗口关闭，进入出牌
+type PlayStarted struct {
+	LeaderSeat int `json:"leaderSeat"`
+}
+
+// CardsPlayed 实际打出的牌；甩牌失败时为裁剪后的牌，并在此之前先有一条 ThrowFailed
+type CardsPlayed struct {
+	Seat      int             `json:"seat"`
+	Trick     int             `json:"trick"` // 本小局第几墩，从0开始
+	Lead      bool            `json:"lead"`
+	Throw     bool            `json:"throw,omitempty"`   // 先手甩牌成功
+	Padding   bool            `json:"padding,omitempty"` // 跟牌垫牌
+	SuitClass rules.SuitClass `json:"suitClass"`
+	Cards     []rules.Card    `json:"cards"`
+}
+
+// ThrowFailed 先手甩牌失败：Intended 为原计划甩出的牌，Played 为实际打出的最小一组
+type ThrowFailed struct {
+	Seat      int             `json:"seat"`
+	SuitClass rules.SuitClass `json:"suitClass"`
+	Intended  []rules.Card    `json:"intended"`
+	Played    []rules.Card    `json:"played"`
+}
+
+// TrickWon 一墩结束；CallerSide 为 true 时分数不计入打家
+type TrickWon struct {
+	Trick         int  `json:"trick"`
+	Seat          int  `json:"seat"`
+	CallerSide    bool `json:"callerSide"`
+	Points        int  `json:"points"`
+	DefenderTotal int  `json:"defenderTotal"`
+}
+
+// BottomDug 末墩公开底牌；Dug 为 false 时 Reason 为不可挖底的原因
+type BottomDug struct {
+	Seat   int          `json:"seat"` // 末墩赢家
+	Cards  []rules.Card `json:"cards"`
+	Base   int          `json:"base"`
+	Mul    int          `json:"mul"`
+	Award  int          `json:"award"`
+	Dug    bool         `json:"dug"`
+	Reason NoticeCode   `json:"reason,omitempty"`
+}
+
+// RoundSettled 小局结算
+type RoundSettled struct {
+	Round           int           `json:"round"`
+	Points          int           `json:"points"`
+	Label           string        `json:"label"`
+	CallerTeam      int           `json:"callerTeam"`
+	CallerDelta     int           `json:"callerDelta"`
+	DefenderDelta   int           `json:"defenderDelta"`
+	Levels          [2]rules.Rank `json:"levels"`
+	NextStarterSeat int           `json:"nextStarterSeat"`
+}
+
+// MatchOver 某队打过 A，整局结束
+type MatchOver struct {
+	WinnerTeam  int           `json:"winnerTeam"`
+	Rounds      int           `json:"rounds"`
+	FinalLevels [2]rules.Rank `json:"finalLevels"`
+}
+
+func (RoundDealt) EventKind() EventKind    { return EkRoundDealt }
+func (TrumpCalled) EventKind() EventKind   { return EkTrumpCalled }
+func (NoTrump) EventKind() EventKind       { return EkNoTrump }
+func (TrumpChanged) EventKind() EventKind  { return EkTrumpChanged }
+func (TrumpAttacked) EventKind() EventKind { return EkTrumpAttacked }
+func (BottomBuried) EventKind() EventKind  { return EkBottomBuried }
+func (PlayStarted) EventKind() EventKind   { return EkPlayStarted }
+func (CardsPlayed) EventKind() EventKind   { return EkCardsPlayed }
+func (ThrowFailed) EventKind() EventKind   { return EkThrowFailed }
+func (TrickWon) EventKind() EventKind      { return EkTrickWon }
+func (BottomDug) EventKind() EventKind     { return EkBottomDug }
+func (RoundSettled) EventKind() EventKind  { return EkRoundSettled }
+func (MatchOver) EventKind() EventKind     { return EkMatchOver }
+
+// roundDealt startDeal 之后调用
+func roundDealt(st *GameState) RoundDealt {
+	starter := st.CallerSeat
+	if st.CallMode == CallModeRace {
+		starter = -1
+	}
+	return RoundDealt{
+		Round:       st.RoundIndex,
+		CallMode:    st.CallMode,
+		StarterSeat: starter,
+		Levels:      [2]rules.Rank{st.Teams[0].LevelRank, st.Teams[1].LevelRank},
+	}
+}
+
+// publicCards 事件中的牌只保留牌面（牌域随主变化）
+func publicCards(cards []rules.Card) []rules.Card {
+	out := make([]rules.Card, len(cards))
+	for i, c := range cards {
+		c.SuitClass = ""
+		out[i] = c
+	}
+	return out
+}
@@ -4,13 +4,29 @@ import (
 	"fmt"
 )
 
+// ErrCategory 错误分类，前端据此决定提示方式
+// - reject：业务拒绝（规则、状态机、权限），请求本身合法但当前不允许，提示后可继续操作
+// - invalid：非法请求（协议、payload 格式、参数越界），通常是客户端 bug 或伪造请求
+// - system：系统错误（服务端内部失败），与玩家操作无关
+type ErrCategory string
+
+const (
+	CatReject  ErrCategory = "reject"
+	CatInvalid ErrCategory = "invalid"
+	CatSystem  ErrCategory = "system"
+)
+
 // AppError 是整个后端统一使用的错误类型
 // - Code: 稳定、可机读，用于前端/UI/测试/replay
 // - Msg:  给人看的简要信息（可英文，前端可自行映射文案）
+// - Info: 本次出错的具体说明（可空）
+//
+// 包级 Err 变量是共享的模板，不可修改：WithInfo / WithInfof / ClearInfo 都返回副本
 type AppError struct {
-	Code string `json:"code"`
-	Msg  string `json:"message"`
-	Info string `json:"info"`
+	Code     string      `json:"code"`
+	Category ErrCategory `json:"category"`
+	Msg      string      `json:"message"`
+	Info     string      `json:"info"`
 }
 
 func (e *AppError) Error() string {
@@ -20,70 +36,132 @@ func (e *AppError) Error() string {
 	return fmt.Sprintf("%s (%s)", e.Msg, e.Code)
 }
 
+// Is 按 Code 比较，errors.Is(err, ErrStateNotYourTurn) 对带 Info 的副本同样成立
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && e != nil && t != nil && e.Code == t.Code
+}
+
 // NewErr 创建一个最基础的 AppError
-func NewErr(code, msg string) *AppError {
+func NewErr(cat ErrCategory, code, msg string) *AppError {
 	return &AppError{
-		Code: code,
-		Msg:  msg,
+		Code:     code,
+		Category: cat,
+		Msg:      msg,
 	}
 }
 
-// WithInfo 给已有 AppError 附加 Info
+// WithInfo 返回附加了 Info 的副本
 func (e *AppError) WithInfo(info string) *AppError {
 	if e == nil {
 		return nil
 	}
-	e.Info = info
-	return e
+	cp := *e
+	cp.Info = info
+	return &cp
 }
 
 func (e *AppError) WithInfof(format string, a ...any) *AppError {
-	if e == nil {
-		return nil
-	}
-	e.Info = fmt.Sprintf(format, a...)
-	return e
+	return e.WithInfo(fmt.Sprintf(format, a...))
 }
 
+// ClearInfo 返回去掉 Info 的副本
 func (e *AppError) ClearInfo() *AppError {
-	if e == nil {
-		return nil
+	return e.WithInfo("")
+}
+
+// NewErrorMsg event / reqID 可空
+func NewErrorMsg(err *AppError, event, reqID string) ErrorMsg {
+	return ErrorMsg{
+		Type:     "error",
+		Code:     err.Code,
+		Category: err.Category,
+		Message:  err.Msg,
+		Info:     err.Info,
+		Event:    event,
+		ReqID:    reqID,
 	}
-	e.Info = ""
-	return e
 }
 
-// 以下 err 变量会在各处使用，要注意 Info 的更改/清空（使用 WithInfo / ClearInfo），否则会出现错误的提示信息
-// ---------- 协议 / Payload 校验错误（router 层）----------
+// WireMessage 供不依赖 game 包的上层（如 ws 握手拒绝）取出完整的错误消息
+func (e *AppError) WireMessage() any { return NewErrorMsg(e, "", "") }
+
+// 以下 err 变量会在各处（多个房间 goroutine）并发使用，只能通过 WithInfo / WithInfof 取带说明的副本
+// ---------- 协议 / Payload 校验错误（router 层，非法请求）----------
+var (
+	ErrBadJSON        = NewErr(CatInvalid, "PROTO_BAD_JSON", "JSON 数据格式错误")
+	ErrUnknownEvent   = NewErr(CatInvalid, "PROTO_UNKNOWN_EVENT", "未知的事件类型")
+	ErrInvalidPayload = NewErr(CatInvalid, "PROTO_INVALID_PAYLOAD", "请求数据不合法")
+	ErrDuplicateIDs   = NewErr(CatInvalid, "PROTO_DUPLICATE_IDS", "存在重复的卡牌 ID")
+	ErrEmptyCards     = NewErr(CatInvalid, "PROTO_EMPTY_CARDS", "未选择任何卡牌")
+	ErrSeatRange      = NewErr(CatInvalid, "PROTO_SEAT_OUT_OF_RANGE", "座位号超出范围")
+	ErrDuplicateOps   = NewErr(CatInvalid, "PROTO_DUPLICATE_OPS", "存在重复的操作")
+	ErrWrongCardsNum  = NewErr(CatInvalid, "PROTO_WRONG_CARD_NUM", "卡牌数量不正确")
+)
+
+// ---------- 规则拒绝错误（rules 层，业务拒绝）----------
+var (
+	ErrRuleIllegalPlay   = NewErr(CatReject, "RULE_ILLEGAL_PLAY", "出牌不符合规则")
+	ErrRuleIllegalFollow = NewErr(CatReject, "RULE_ILLEGAL_FOLLOW", "跟牌不符合规则")
+	ErrRuleIllegalTrump  = NewErr(CatReject, "RULE_ILLEGAL_TRUMP", "主牌使用不合法")
+	ErrRuleCardReused    = NewErr(CatReject, "RULE_CARD_REUSED", "同一张王牌或级牌本小局只能亮一次")
+)
+
+// ---------- 状态机错误（game / engine 层，业务拒绝）----------
+var (
+	ErrStateNotYourTurn = NewErr(CatReject, "STATE_NOT_YOUR_TURN", "未轮到你操作")
+	ErrStateWrongPhase  = NewErr(CatReject, "STATE_WRONG_PHASE", "当前阶段不允许该操作")
+	ErrStateNotSeated   = NewErr(CatReject, "STATE_NOT_SEATED", "玩家尚未入座")
+	ErrStateSeatTaken   = NewErr(CatReject, "STATE_TAKEN", "该座位已被占用")
+	ErrStateNotReady    = NewErr(CatReject, "STATE_NOT_READY", "玩家尚未准备")
+	ErrStateSpectator   = NewErr(CatReject, "STATE_SPECTATOR", "观战者不能操作")
+)
+
+// ---------- 房间错误（room 层，业务拒绝；房间号、规则方案格式错误为非法请求）----------
+var (
+	ErrRoomNotFound  = NewErr(CatReject, "ROOM_NOT_FOUND", "房间不存在")
+	ErrRoomExists    = NewErr(CatReject, "ROOM_EXISTS", "房间已存在")
+	ErrRoomInvalidID = NewErr(CatInvalid, "ROOM_INVALID_ID", "房间号不合法")
+	ErrRoomChatRate  = NewErr(CatReject, "ROOM_CHAT_RATE", "发言过于频繁，请稍后再试")
+
+	ErrRoomPasswordRequired = NewErr(CatReject, "ROOM_PASSWORD_REQUIRED", "该房间需要密码")
+	ErrRoomBadPassword      = NewErr(CatReject, "ROOM_BAD_PASSWORD", "房间密码错误")
+	ErrRoomInviteOnly       = NewErr(CatReject, "ROOM_INVITE_ONLY", "该房间仅限受邀加入")
+	ErrRoomInviteInvalid    = NewErr(CatReject, "ROOM_INVITE_INVALID", "邀请已失效（已被使用或已过期）")
+	ErrRoomKicked           = NewErr(CatReject, "ROOM_KICKED", "你已被房主移出该房间")
+
+	ErrRoomNotHost    = NewErr(CatReject, "ROOM_NOT_HOST", "只有房主可以执行该操作")
+	ErrRoomSeatLocked = NewErr(CatReject, "ROOM_SEAT_LOCKED", "该座位已被锁定")
+	ErrRoomBadConfig  = NewErr(CatInvalid, "ROOM_BAD_CONFIG", "房间规则方案不合法")
+
+	ErrRoomUndoDisabled = NewErr(CatReject, "ROOM_UNDO_DISABLED", "本房间已关闭悔棋")
+	ErrRoomUndoEmpty    = NewErr(CatReject, "ROOM_UNDO_EMPTY", "没有可以撤回的操作")
+	ErrRoomUndoNoVote   = NewErr(CatReject, "ROOM_UNDO_NO_VOTE", "当前没有待表决的悔棋请求")
+)
+
+// ---------- 会话错误（session 层，业务拒绝）----------
 var (
-	ErrBadJSON        = NewErr("PROTO_BAD_JSON", "JSON 数据格式错误")
-	ErrUnknownEvent   = NewErr("PROTO_UNKNOWN_EVENT", "未知的事件类型")
-	ErrInvalidPayload = NewErr("PROTO_INVALID_PAYLOAD", "请求数据不合法")
-	ErrDuplicateIDs   = NewErr("PROTO_DUPLICATE_IDS", "存在重复的卡牌 ID")
-	ErrEmptyCards     = NewErr("PROTO_EMPTY_CARDS", "未选择任何卡牌")
-	ErrSeatRange      = NewErr("PROTO_SEAT_OUT_OF_RANGE", "座位号超出范围")
-	ErrDuplicateOps   = NewErr("PROTO_DUPLICATE_OPS", "存在重复的操作")
-	ErrWrongCardsNum  = NewErr("PROTO_WRONG_CARD_NUM", "卡牌数量不正确")
+	ErrSessionRequired = NewErr(CatReject, "SESSION_REQUIRED", "请先获取会话 token")
+	ErrSessionInvalid  = NewErr(CatReject, "SESSION_INVALID", "会话 token 无效")
+	ErrSessionExpired  = NewErr(CatReject, "SESSION_EXPIRED", "会话已过期，请重新登录")
 )
 
-// ---------- 规则拒绝错误（rules 层）----------
+// ---------- 统计错误（stats 层，业务拒绝；排序字段错误为非法请求）----------
 var (
-	ErrRuleIllegalPlay   = NewErr("RULE_ILLEGAL_PLAY", "出牌不符合规则")
-	ErrRuleIllegalFollow = NewErr("RULE_ILLEGAL_FOLLOW", "跟牌不符合规则")
-	ErrRuleIllegalTrump  = NewErr("RULE_ILLEGAL_TRUMP", "主牌使用不合法")
+	ErrStatsNoPlayer = NewErr(CatReject, "STATS_NO_PLAYER", "没有该玩家的统计")
+	ErrStatsBadSort  = NewErr(CatInvalid, "STATS_BAD_SORT", "不支持的排序字段")
 )
 
-// ---------- 状态机错误（game / engine 层）----------
+// ---------- 牌谱错误（导入格式错误、无法重放为非法请求）----------
 var (
-	ErrStateNotYourTurn = NewErr("STATE_NOT_YOUR_TURN", "未轮到你操作")
-	ErrStateWrongPhase  = NewErr("STATE_WRONG_PHASE", "当前阶段不允许该操作")
-	ErrStateNotSeated   = NewErr("STATE_NOT_SEATED", "玩家尚未入座")
-	ErrStateSeatTaken   = NewErr("STATE_TAKEN", "该座位已被占用")
-	ErrStateNotReady    = NewErr("STATE_NOT_READY", "玩家尚未准备")
+	ErrHandSyntax   = NewErr(CatInvalid, "HAND_SYNTAX", "牌谱格式错误")
+	ErrHandReplay   = NewErr(CatInvalid, "HAND_REPLAY", "牌谱无法重放")
+	ErrHandNotFound = NewErr(CatReject, "HAND_NOT_FOUND", "没有该小局的牌谱")
+	ErrHandPrivate  = NewErr(CatReject, "HAND_PRIVATE", "私密房间的牌谱仅限房间成员下载")
 )
 
 // ---------- 系统错误（不可恢复，通常只记日志）----------
 var (
-	ErrSystem = NewErr("SYS_INTERNAL_ERROR", "服务器内部错误")
-	ErrFatal  = NewErr("SYS_FATAL_ERROR", "服务器发生严重错误")
+	ErrSystem = NewErr(CatSystem, "SYS_INTERNAL_ERROR", "服务器内部错误")
+	ErrFatal  = NewErr(CatSystem, "SYS_FATAL_ERROR", "服务器发生严重错误")
 )
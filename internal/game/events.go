@@ -8,8 +8,11 @@ const (
 	EvReady   ClientEventType = "room.ready"
 	EvUnready ClientEventType = "room.unready"
 
+	EvSettings ClientEventType = "room.settings" // 房间设置（仅大厅；room 层限制为房主）
+
 	EvStart          ClientEventType = "game.start"
 	EvStartNextRound ClientEventType = "game.start_next_round"
+	EvRematch        ClientEventType = "game.rematch"
 
 	EvCallTrump   ClientEventType = "game.call_trump"
 	EvCallPass    ClientEventType = "game.call_pass"
@@ -18,6 +21,10 @@ const (
 	EvAttackTrump ClientEventType = "game.attack_trump"
 
 	EvPlayCards ClientEventType = "game.play_cards"
+
+	// 聊天/表情：由 room 直接广播，不进入 Reduce、不改变 Version
+	EvChat  ClientEventType = "room.chat"
+	EvEmote ClientEventType = "room.emote"
 )
 
 // ---- 请求Payload ----
@@ -26,6 +33,23 @@ type SitPayload struct {
 	Seat int `json:"seat"`
 }
 
+// StartPayload 手动开局；Force 为 true 时不要求所有人准备（仍需四人入座），room 层限制为房主
+type StartPayload struct {
+	Force bool `json:"force"`
+}
+
+// SettingsPayload 房间设置，字段为空表示不修改
+// - Profile：切换到内置规则方案（见 RoomPresets）
+// - Config：自定义规则方案，与 Profile 二选一
+// - Timers：各阶段操作时限与棋钟（秒）
+type SettingsPayload struct {
+	HideRecord  *bool       `json:"hideRecord,omitempty"`
+	DisableUndo *bool       `json:"disableUndo,omitempty"`
+	Profile     string      `json:"profile,omitempty"`
+	Config      *RoomConfig `json:"config,omitempty"`
+	Timers      *TurnTimers `json:"timers,omitempty"`
+}
+
 // CallTrumpPayload 定主：公开用哪些牌定主
 // levelIds: 1张表示普通定主；2张表示“一对级牌” -> 触发锁主（同色王 + 一对级牌）
 type CallTrumpPayload struct {
@@ -59,6 +83,32 @@ func (p SitPayload) Validate() *AppError {
 	return nil
 }
 
+func (p SettingsPayload) Validate() *AppError {
+	if p.HideRecord == nil && p.DisableUndo == nil && p.Profile == "" && p.Config == nil && p.Timers == nil {
+		return ErrInvalidPayload.WithInfo("没有需要修改的设置")
+	}
+	if p.Timers != nil {
+		if err := p.Timers.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.Profile != "" && p.Config != nil {
+		return ErrInvalidPayload.WithInfo("内置方案与自定义方案只能选择一个")
+	}
+	if p.Profile != "" {
+		if _, ok := RoomPreset(p.Profile); !ok {
+			return ErrRoomBadConfig.WithInfof("没有名为 %s 的内置方案", p.Profile)
+		}
+	}
+	if p.Config != nil {
+		if _, ok := RoomPreset(p.Config.Profile); ok {
+			return ErrRoomBadConfig.WithInfo("自定义方案不能使用内置方案的名称")
+		}
+		return p.Config.Validate()
+	}
+	return nil
+}
+
 func (p CallTrumpPayload) Validate() *AppError {
 	if err := validateLenIn(p.LevelIDs, 1, 2); err != nil {
 		return err
@@ -0,0 +1,678 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"upgrade-lan/internal/game/rules"
+)
+
+// 牌谱：一小局的行式文本记录，格式说明见根目录 牌谱格式.md
+// - 导出：从发牌后的 state 依次重放 Steps，边重放边写出定主、扣底、每墩出牌，最后写结算
+// - 导入：解析为同样的 HandHistory，并重放校验（被拒绝的步骤、与记录不一致的结算都报出行号）
+
+const handHeader = "upgrade-hand 1"
+
+// HandStep 一次 Reduce 输入；Payload 与 room.ParseClientEvent 的产物相同（如 PlayCardsPayload）
+type HandStep struct {
+	UID     string
+	Type    ClientEventType
+	Payload any
+}
+
+// HandHistory 一小局：发牌后的完整 state（含四家手牌与底牌），以及之后依次喂给 Reduce 的输入
+type HandHistory struct {
+	Dealt GameState
+	Steps []HandStep
+}
+
+// Replay 从 Dealt 依次重放 Steps，返回最终 state（不修改 Dealt）
+func (h HandHistory) Replay() (GameState, *AppError) {
+	st := h.Dealt.Clone()
+	for i, s := range h.Steps {
+		next, err := reduceStep(st, s)
+		if err != nil {
+			return st, ErrHandReplay.WithInfof("第%d步 %s %s：%s", i+1, s.UID, s.Type, err.Error())
+		}
+		st = next
+	}
+	return st, nil
+}
+
+// reduceStep 小局内不会再发牌，种子来源为空
+func reduceStep(st GameState, s HandStep) (GameState, *AppError) {
+	res, err := Reduce(st, NewFixedSeedSource(), s.UID, s.Type, s.Payload)
+	if err != nil {
+		return st, err
+	}
+	return res.State, nil
+}
+
+// 事件类型 <-> 牌谱关键字；定主阶段的不定主与改主/攻主阶段的跳过都是 EvCallPass
+var handKeywords = map[ClientEventType]string{
+	EvCallPass:    "pass",
+	EvCallTrump:   "call",
+	EvPutBottom:   "bury",
+	EvChangeTrump: "change",
+	EvAttackTrump: "attack",
+	EvPlayCards:   "play",
+}
+
+// stepCardIDs 步骤中亮出/扣下/打出的牌；定主、改主时王牌在前
+func stepCardIDs(s HandStep) ([]int, bool) {
+	switch p := s.Payload.(type) {
+	case struct{}:
+		return nil, s.Type == EvCallPass
+	case CallTrumpPayload:
+		return append([]int{p.JokerID}, p.LevelIDs...), true
+	case ChangeTrumpPayload:
+		return append([]int{p.JokerID}, p.LevelIDs...), true
+	case AttackTrumpPayload:
+		return p.JokerIDs, true
+	case PutBottomPayload:
+		return p.DiscardIDs, true
+	case PlayCardsPayload:
+		return p.CardIDs, true
+	default:
+		return nil, false
+	}
+}
+
+// stepPayload 由关键字和牌还原 payload，校验与 router 相同
+func stepPayload(typ ClientEventType, ids []int) (any, *AppError) {
+	switch typ {
+	case EvCallPass:
+		if len(ids) > 0 {
+			return nil, ErrWrongCardsNum.WithInfo("pass 不带牌")
+		}
+		return struct{}{}, nil
+	case EvCallTrump:
+		if len(ids) == 0 {
+			return nil, ErrWrongCardsNum.WithInfo("缺少定主王牌")
+		}
+		p := CallTrumpPayload{JokerID: ids[0], LevelIDs: ids[1:]}
+		return p, p.Validate()
+	case EvChangeTrump:
+		if len(ids) == 0 {
+			return nil, ErrWrongCardsNum.WithInfo("缺少定主王牌")
+		}
+		p := ChangeTrumpPayload{JokerID: ids[0], LevelIDs: ids[1:]}
+		return p, p.Validate()
+	case EvAttackTrump:
+		p := AttackTrumpPayload{JokerIDs: ids}
+		return p, p.Validate()
+	case EvPutBottom:
+		p := PutBottomPayload{DiscardIDs: ids}
+		return p, p.Validate()
+	default: // EvPlayCards
+		p := PlayCardsPayload{CardIDs: ids}
+		return p, p.Validate()
+	}
+}
+
+// ---------- 牌面 ----------
+
+// 两副牌的 ID 固定（见 rules.NewDoubleDeck），牌谱中的 "S10#4" 由牌面 + ID 组成，牌面仅供阅读并在导入时校验
+var handDeck = rules.NewDoubleDeck()
+
+var suitLetters = map[rules.Suit]string{
+	rules.Spade:   "S",
+	rules.Heart:   "H",
+	rules.Club:    "C",
+	rules.Diamond: "D",
+}
+
+// CardFace 牌面简写：花色 S/H/C/D + 点数（如 S10、HA），小王 LJ、大王 BJ；牌谱与终端客户端共用
+func CardFace(c rules.Card) string {
+	switch c.Suit {
+	case rules.SmallJoker:
+		return "LJ"
+	case rules.BigJoker:
+		return "BJ"
+	default:
+		return suitLetters[c.Suit] + string(c.Rank)
+	}
+}
+
+func cardToken(c rules.Card) string {
+	return CardFace(c) + "#" + strconv.Itoa(c.ID)
+}
+
+func idsToken(ids []int) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id < 0 || id >= len(handDeck) {
+			parts = append(parts, "?#"+strconv.Itoa(id))
+			continue
+		}
+		parts = append(parts, cardToken(handDeck[id]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func cardsToken(cards []rules.Card) string {
+	parts := make([]string, 0, len(cards))
+	for _, c := range cards {
+		parts = append(parts, cardToken(c))
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseCardID(tok string) (int, *AppError) {
+	face, num, ok := strings.Cut(tok, "#")
+	id, err := strconv.Atoi(num)
+	if !ok || err != nil || id < 0 || id >= len(handDeck) {
+		return 0, ErrHandSyntax.WithInfof("无法识别的牌 %s", tok)
+	}
+	if want := CardFace(handDeck[id]); face != want {
+		return 0, ErrHandSyntax.WithInfof("牌 %s 的牌面应为 %s", tok, want)
+	}
+	return id, nil
+}
+
+// ---------- 导出 ----------
+
+// ExportHand 按牌谱格式写出一小局；Steps 被拒绝时返回 ErrHandReplay
+func ExportHand(h HandHistory) (string, *AppError) {
+	var b strings.Builder
+	line := func(format string, a ...any) { fmt.Fprintf(&b, format+"\n", a...) }
+
+	st := h.Dealt.Clone()
+	line("%s", handHeader)
+	line("room %s", escapeArg(st.RoomID))
+	line("round %d", st.RoundIndex)
+	if _, ok := RoomPreset(st.Config.Profile); ok {
+		line("config %s", st.Config.Profile)
+	} else {
+		cfg, err := json.Marshal(st.Config)
+		if err != nil {
+			return "", ErrSystem.WithInfof("规则方案编码失败: %v", err)
+		}
+		line("config %s", cfg)
+	}
+	line("levels %s %s", st.Teams[0].LevelRank, st.Teams[1].LevelRank)
+	for i := 0; i < 4; i++ {
+		line("seat %d %s", i, escapeArg(st.Seats[i].UID))
+	}
+	starter := st.CallerSeat
+	if st.CallMode == CallModeRace {
+		starter = -1
+	}
+	line("starter %d", starter)
+	line("seed %s", st.DealSeed)
+	for i := 0; i < 4; i++ {
+		line("hand %d %s", i, cardsToken(st.Seats[i].Hand))
+	}
+	line("bottom %s", cardsToken(st.Bottom))
+
+	for i, s := range h.Steps {
+		kw, ok := handKeywords[s.Type]
+		ids, okIDs := stepCardIDs(s)
+		if !ok || !okIDs {
+			return "", ErrHandReplay.WithInfof("第%d步 %s 不是小局内的操作", i+1, s.Type)
+		}
+		seat, err := seatIndexByUID(&st, s.UID)
+		if err != nil {
+			return "", ErrHandReplay.WithInfof("第%d步 %s 不在座位上", i+1, s.UID)
+		}
+		if s.Type == EvPlayCards && isTrickEmpty(&st.Trick) {
+			order := make([]string, 4)
+			for k := 0; k < 4; k++ {
+				order[k] = strconv.Itoa((st.Trick.LeaderSeat + k) % 4)
+			}
+			line("trick %d %s", st.TrickIndex, strings.Join(order, " "))
+		}
+		if len(ids) == 0 {
+			line("%s %d", kw, seat)
+		} else {
+			line("%s %d %s", kw, seat, idsToken(ids))
+		}
+
+		trick := st.TrickIndex
+		next, err := reduceStep(st, s)
+		if err != nil {
+			return "", ErrHandReplay.WithInfof("第%d步 %s %s：%s", i+1, s.UID, s.Type, err.Error())
+		}
+		st = next
+		if s.Type != EvPlayCards {
+			continue
+		}
+		plays := &st.Trick.Plays
+		if st.TrickIndex != trick {
+			plays = &st.Trick.LastPlays
+		}
+		if mv := plays[seat]; mv != nil && len(mv.Cards) != len(ids) {
+			line("# 甩牌失败，实际打出 %s", cardsToken(mv.Cards))
+		}
+		if st.TrickIndex != trick {
+			points := 0
+			for _, mv := range st.Trick.LastPlays {
+				if mv != nil {
+					points += rules.TrickPoints(mv.Cards)
+				}
+			}
+			side := "打家"
+			if inCallerGroup(&st, st.Trick.WinnerSeat) {
+				side = "坐家"
+			}
+			line("# 第%d墩赢家 %d号位（%s），分牌 %d", trick, st.Trick.WinnerSeat, side, points)
+		}
+	}
+
+	if st.Phase == PhaseRoundSettle || st.Phase == PhaseGameOver {
+		line("dig %s", strings.Join(digFields(&st), " "))
+		line("settle %s", strings.Join(settleFields(&st), " "))
+	}
+	return b.String(), nil
+}
+
+func isTrickEmpty(tr *TrickState) bool {
+	for i := 0; i < 4; i++ {
+		if tr.Plays[i] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// settleFields / digFields settle、dig 行的 key=value，导入时逐项与重放结果比对
+func settleFields(st *GameState) []string {
+	return []string{
+		"points=" + strconv.Itoa(st.RoundPointsFinal),
+		"label=" + escapeArg(st.RoundResultLabel),
+		"caller=" + strconv.Itoa(st.CallerDelta),
+		"defender=" + strconv.Itoa(st.DefenderDelta),
+		"levels=" + string(st.Teams[0].LevelRank) + "," + string(st.Teams[1].LevelRank),
+		"next=" + strconv.Itoa(st.NextStarterSeat),
+	}
+}
+
+func digFields(st *GameState) []string {
+	return []string{
+		"base=" + strconv.Itoa(st.BottomPoints),
+		"mul=" + strconv.Itoa(st.BottomMul),
+		"award=" + strconv.Itoa(st.BottomAward),
+	}
+}
+
+// ---------- 导入 ----------
+
+// ParseHand 解析牌谱为 HandHistory：Dealt 为发牌后的 state，Steps 可直接依次喂给 Reduce
+// 解析后会重放一遍，出错时 Info 中带行号
+func ParseHand(r io.Reader) (HandHistory, *AppError) {
+	p := handParser{
+		st:      NewGameState(""),
+		hands:   make(map[int][]rules.Card),
+		starter: -2,
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		p.line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if err := p.parseLine(text); err != nil {
+			return HandHistory{}, p.fail(err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return HandHistory{}, ErrHandSyntax.WithInfof("读取失败: %v", err)
+	}
+	return p.finish()
+}
+
+type handParser struct {
+	line    int
+	header  bool
+	st      GameState // 发牌前：座位、级牌、规则方案、小局序号
+	seed    rules.Seed
+	starter int // -2 表示还未出现
+	hands   map[int][]rules.Card
+	bottom  []rules.Card
+	dealt   bool
+	steps   []HandStep
+	lines   []int // 每个 step 所在行
+	trick   int   // 上一个 trick 行的墩序号，-1 表示还未出现
+	order   []int // 上一个 trick 行的出牌顺序
+	played  int   // 本墩已出牌的人数
+	dig     []string
+	settle  []string
+	endLine int
+}
+
+func (p *handParser) fail(err *AppError) *AppError {
+	info := err.Info
+	if info == "" {
+		info = err.Msg
+	}
+	return err.WithInfof("第%d行：%s", p.line, info)
+}
+
+func (p *handParser) parseLine(text string) *AppError {
+	if !p.header {
+		if text != handHeader {
+			return ErrHandSyntax.WithInfof("首行应为 %q", handHeader)
+		}
+		p.header = true
+		return nil
+	}
+	kw, rest, _ := strings.Cut(text, " ")
+	rest = strings.TrimSpace(rest)
+	args := strings.Fields(rest)
+	if p.settle != nil {
+		return ErrHandSyntax.WithInfo("settle 之后不能再有内容")
+	}
+
+	switch kw {
+	case "room":
+		id, err := unescapeArg(args, 0)
+		if err != nil {
+			return err
+		}
+		p.st.RoomID = id
+	case "round":
+		n, err := intArg(args, 0)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return ErrHandSyntax.WithInfo("round 不能为负数")
+		}
+		p.st.RoundIndex = n
+	case "config":
+		if cfg, ok := RoomPreset(rest); ok {
+			p.st.Config = cfg
+			return nil
+		}
+		var cfg RoomConfig
+		if err := json.Unmarshal([]byte(rest), &cfg); err != nil {
+			return ErrHandSyntax.WithInfof("无法识别的规则方案 %s", rest)
+		}
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		p.st.Config = cfg
+	case "levels":
+		if len(args) != 2 {
+			return ErrHandSyntax.WithInfo("levels 需要两队的级牌")
+		}
+		for t := 0; t < 2; t++ {
+			rank := rules.Rank(args[t])
+			if v := rank.BaseValue(); v < 2 || v > 14 {
+				return ErrHandSyntax.WithInfof("无法识别的级牌 %s", args[t])
+			}
+			p.st.Teams[t].LevelRank = rank
+		}
+	case "seat":
+		seat, err := seatArg(args, 0)
+		if err != nil {
+			return err
+		}
+		uid, err := unescapeArg(args, 1)
+		if err != nil {
+			return err
+		}
+		p.st.Seats[seat].UID = uid
+		p.st.Seats[seat].Online = true
+	case "starter":
+		n, err := intArg(args, 0)
+		if err != nil {
+			return err
+		}
+		if n < -1 || n >= 4 {
+			return ErrSeatRange.WithInfof("starter %d", n)
+		}
+		p.starter = n
+	case "seed":
+		seed, err := rules.ParseSeed(firstArg(args))
+		if err != nil {
+			return ErrHandSyntax.WithInfo("seed 应为 64 位十六进制或整数")
+		}
+		p.seed = seed
+	case "hand":
+		seat, err := seatArg(args, 0)
+		if err != nil {
+			return err
+		}
+		cards, err := parseCards(args[1:])
+		if err != nil {
+			return err
+		}
+		p.hands[seat] = cards
+	case "bottom":
+		cards, err := parseCards(args)
+		if err != nil {
+			return err
+		}
+		p.bottom = cards
+	case "trick":
+		return p.parseTrick(args)
+	case "dig":
+		p.dig = args
+	case "settle":
+		p.settle = args
+		p.endLine = p.line
+	default:
+		for typ, name := range handKeywords {
+			if name == kw {
+				return p.parseStep(typ, args)
+			}
+		}
+		return ErrHandSyntax.WithInfof("未知的关键字 %s", kw)
+	}
+	return nil
+}
+
+func (p *handParser) parseTrick(args []string) *AppError {
+	if p.order != nil && p.played != 4 {
+		return ErrHandSyntax.WithInfof("第%d墩还有%d人未出牌", p.trick, 4-p.played)
+	}
+	n, err := intArg(args, 0)
+	if err != nil {
+		return err
+	}
+	if len(args) != 5 {
+		return ErrHandSyntax.WithInfo("trick 需要墩序号和四个座位的出牌顺序")
+	}
+	order := make([]int, 4)
+	for i := range order {
+		seat, err := seatArg(args, i+1)
+		if err != nil {
+			return err
+		}
+		if want := (order[0] + i) % 4; i > 0 && seat != want {
+			return ErrHandSyntax.WithInfof("出牌顺序应从先手依次轮转，第%d个座位应为%d", i+1, want)
+		}
+		order[i] = seat
+	}
+	p.trick, p.order, p.played = n, order, 0
+	return nil
+}
+
+func (p *handParser) parseStep(typ ClientEventType, args []string) *AppError {
+	if !p.dealt {
+		if err := p.deal(); err != nil {
+			return err
+		}
+	}
+	seat, err := seatArg(args, 0)
+	if err != nil {
+		return err
+	}
+	cards, err := parseCards(args[1:])
+	if err != nil {
+		return err
+	}
+	if typ == EvPlayCards {
+		if p.order == nil || p.played >= 4 {
+			return ErrHandSyntax.WithInfo("play 之前缺少 trick 行")
+		}
+		if want := p.order[p.played]; seat != want {
+			return ErrHandSyntax.WithInfof("本墩第%d个出牌的应为%d号位", p.played+1, want)
+		}
+		p.played++
+	}
+	ids := make([]int, len(cards))
+	for i, c := range cards {
+		ids[i] = c.ID
+	}
+	payload, err := stepPayload(typ, ids)
+	if err != nil {
+		return err
+	}
+	p.steps = append(p.steps, HandStep{UID: p.st.Seats[seat].UID, Type: typ, Payload: payload})
+	p.lines = append(p.lines, p.line)
+	return nil
+}
+
+// deal 第一个操作出现时，手牌、底牌必须齐全：四家各25张、底牌8张，恰好是两副牌
+func (p *handParser) deal() *AppError {
+	var hands [4][]rules.Card
+	seen := make(map[int]bool, len(handDeck))
+	for i := 0; i < 4; i++ {
+		if p.st.Seats[i].UID == "" {
+			return ErrHandSyntax.WithInfof("缺少 seat %d", i)
+		}
+		if len(p.hands[i]) != 25 {
+			return ErrWrongCardsNum.WithInfof("%d号位手牌应为25张，实际%d张", i, len(p.hands[i]))
+		}
+		hands[i] = p.hands[i]
+	}
+	if len(p.bottom) != 8 {
+		return ErrWrongCardsNum.WithInfof("底牌应为8张，实际%d张", len(p.bottom))
+	}
+	for _, cards := range append(hands[:], p.bottom) {
+		for _, c := range cards {
+			if seen[c.ID] {
+				return ErrDuplicateIDs.WithInfof("牌 %s 出现了两次", cardToken(c))
+			}
+			seen[c.ID] = true
+		}
+	}
+	switch {
+	case p.starter == -2:
+		return ErrHandSyntax.WithInfo("缺少 starter")
+	case p.st.RoundIndex == 0 && p.starter != -1:
+		return ErrHandSyntax.WithInfo("第一小局为抢定主，starter 应为 -1")
+	case p.st.RoundIndex > 0 && p.starter < 0:
+		return ErrHandSyntax.WithInfo("后续小局需要指定 starter 座位")
+	}
+	if p.st.RoundIndex > 0 {
+		p.st.NextStarterSeat = p.starter
+	}
+	dealCards(&p.st, p.seed, hands, p.bottom)
+	p.dealt = true
+	return nil
+}
+
+// finish 重放全部步骤；有 dig/settle 行时逐项与重放结果比对
+func (p *handParser) finish() (HandHistory, *AppError) {
+	if !p.header {
+		return HandHistory{}, ErrHandSyntax.WithInfo("牌谱为空")
+	}
+	if !p.dealt {
+		if err := p.deal(); err != nil {
+			return HandHistory{}, err
+		}
+	}
+	h := HandHistory{Dealt: p.st, Steps: p.steps}
+	st := h.Dealt.Clone()
+	for i, s := range h.Steps {
+		next, err := reduceStep(st, s)
+		if err != nil {
+			return HandHistory{}, ErrHandReplay.WithInfof("第%d行：%s", p.lines[i], err.Error())
+		}
+		st = next
+	}
+	if p.settle == nil {
+		return h, nil
+	}
+	if st.Phase != PhaseRoundSettle && st.Phase != PhaseGameOver {
+		return HandHistory{}, ErrHandReplay.WithInfof("第%d行：重放后小局尚未结束", p.endLine)
+	}
+	if p.dig != nil && !sameFields(p.dig, digFields(&st)) {
+		return HandHistory{}, ErrHandReplay.WithInfof("抠底与重放结果不一致，重放为 %s", strings.Join(digFields(&st), " "))
+	}
+	if !sameFields(p.settle, settleFields(&st)) {
+		return HandHistory{}, ErrHandReplay.WithInfof("第%d行：结算与重放结果不一致，重放为 %s", p.endLine, strings.Join(settleFields(&st), " "))
+	}
+	return h, nil
+}
+
+func sameFields(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+func intArg(args []string, i int) (int, *AppError) {
+	if i >= len(args) {
+		return 0, ErrHandSyntax.WithInfo("参数不足")
+	}
+	n, err := strconv.Atoi(args[i])
+	if err != nil {
+		return 0, ErrHandSyntax.WithInfof("%s 不是整数", args[i])
+	}
+	return n, nil
+}
+
+func seatArg(args []string, i int) (int, *AppError) {
+	n, err := intArg(args, i)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n >= 4 {
+		return 0, ErrSeatRange.WithInfof("座位 %d", n)
+	}
+	return n, nil
+}
+
+// 参数以空白分隔：房间号、uid、结算档位名称中的空白和 % 按 URL 转义，其余字符原样写出
+var argEscaper = strings.NewReplacer("%", "%25", " ", "%20", "\t", "%09")
+
+func escapeArg(s string) string {
+	return argEscaper.Replace(s)
+}
+
+func unescapeArg(args []string, i int) (string, *AppError) {
+	if i >= len(args) {
+		return "", ErrHandSyntax.WithInfo("参数不足")
+	}
+	s, err := url.PathUnescape(args[i])
+	if err != nil || s == "" {
+		return "", ErrHandSyntax.WithInfof("无法识别的参数 %s", args[i])
+	}
+	return s, nil
+}
+
+func parseCards(tokens []string) ([]rules.Card, *AppError) {
+	cards := make([]rules.Card, 0, len(tokens))
+	for _, tok := range tokens {
+		id, err := parseCardID(tok)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, handDeck[id])
+	}
+	return cards, nil
+}
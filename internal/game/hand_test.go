@@ -0,0 +1,413 @@
+package game
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"upgrade-lan/internal/game/rules"
+)
+
+// 牌谱测试：用简单策略模拟整小局（尽量定主、改主、先手甩牌、扣分牌入底），导出后再导入、再导出应逐字节一致
+
+var handUIDs = [4]string{"a b", "c%d", "张三", "d"}
+
+// handSim 记录一小局的 HandHistory，以及出现过的定主/改主/甩牌
+type handSim struct {
+	t      *testing.T
+	st     GameState
+	hist   HandHistory
+	called bool
+	change bool
+	throw  bool
+}
+
+func (s *handSim) apply(seat int, typ ClientEventType, payload any) *AppError {
+	uid := s.st.Seats[seat].UID
+	res, err := Reduce(s.st, NewFixedSeedSource(), uid, typ, payload)
+	if err != nil {
+		return err
+	}
+	s.st = res.State
+	s.hist.Steps = append(s.hist.Steps, HandStep{UID: uid, Type: typ, Payload: payload})
+	return nil
+}
+
+func (s *handSim) must(seat int, typ ClientEventType, payload any) {
+	s.t.Helper()
+	if err := s.apply(seat, typ, payload); err != nil {
+		s.t.Fatalf("%d号位 %s 被拒绝: %v", seat, typ, err)
+	}
+}
+
+// newHandSim 四人入座准备，自动发牌后开始记录
+func newHandSim(t *testing.T, seed int64) *handSim {
+	t.Helper()
+	st := NewGameState("牌谱 测试")
+	seeds := NewFixedSeedSource(rules.SeedFromInt(seed))
+	for i, uid := range handUIDs {
+		res, err := Reduce(st, seeds, uid, EvSit, SitPayload{Seat: i})
+		if err != nil {
+			t.Fatalf("入座失败: %v", err)
+		}
+		st = res.State
+	}
+	for _, uid := range handUIDs {
+		res, err := Reduce(st, seeds, uid, EvReady, struct{}{})
+		if err != nil {
+			t.Fatalf("准备失败: %v", err)
+		}
+		st = res.State
+	}
+	if st.Phase != PhaseCallTrump {
+		t.Fatalf("发牌后阶段为 %s", st.Phase)
+	}
+	return &handSim{t: t, st: st, hist: HandHistory{Dealt: st.Clone()}}
+}
+
+// nextRound 由下一小局的优先定主者开始下一小局
+func (s *handSim) nextRound(seed int64) {
+	s.t.Helper()
+	uid := s.st.Seats[s.st.NextStarterSeat].UID
+	res, err := Reduce(s.st, NewFixedSeedSource(rules.SeedFromInt(seed)), uid, EvStartNextRound, struct{}{})
+	if err != nil {
+		s.t.Fatalf("开始下一小局失败: %v", err)
+	}
+	s.st = res.State
+	s.hist = HandHistory{Dealt: s.st.Clone()}
+	s.called, s.change, s.throw = false, false, false
+}
+
+func (s *handSim) playRound() {
+	s.t.Helper()
+	for n := 0; n < 400; n++ {
+		switch s.st.Phase {
+		case PhaseCallTrump:
+			s.callOrPass()
+		case PhaseBottom:
+			s.bury()
+		case PhaseTrumpFight:
+			s.changeOrPass()
+		case PhasePlayTrick:
+			s.play()
+		case PhaseRoundSettle, PhaseGameOver:
+			return
+		default:
+			s.t.Fatalf("意外的阶段 %s", s.st.Phase)
+		}
+	}
+	s.t.Fatal("小局没有结束")
+}
+
+func (s *handSim) callOrPass() {
+	seat := s.st.CallTurnSeat
+	if s.st.CallMode == CallModeRace {
+		for i := 0; i < 4; i++ {
+			if s.st.CallPassMask&(1<<uint(i)) == 0 {
+				seat = i
+				break
+			}
+		}
+	}
+	level := s.st.Teams[s.st.Seats[seat].Team].LevelRank
+	hand := s.st.Seats[seat].Hand
+	for _, j := range hand {
+		if !rules.IsBigJoker(j) && !rules.IsSmallJoker(j) {
+			continue
+		}
+		for _, lc := range hand {
+			// 只用一张级牌定主（不锁主），留出改主的机会
+			if lc.Rank != level || rules.IsBigJoker(lc) || rules.IsSmallJoker(lc) {
+				continue
+			}
+			if _, _, err := rules.ValidateCallTrump(level, j, []rules.Card{lc}); err != nil {
+				continue
+			}
+			if s.apply(seat, EvCallTrump, CallTrumpPayload{JokerID: j.ID, LevelIDs: []int{lc.ID}}) == nil {
+				s.called = true
+				return
+			}
+		}
+	}
+	s.must(seat, EvCallPass, struct{}{})
+}
+
+// bury 优先把副牌中的分牌扣入底牌，便于末墩抠底有分
+func (s *handSim) bury() {
+	seat := s.st.BottomOwnerSeat
+	hand := s.st.Seats[seat].Hand
+	var ids []int
+	for _, c := range hand {
+		if len(ids) < 8 && c.SuitClass != rules.SCTrump && rules.TrickPoints([]rules.Card{c}) > 0 {
+			ids = append(ids, c.ID)
+		}
+	}
+	for _, c := range rules.CheapestCards(hand, s.st.Trump.Trump, len(hand)) {
+		if len(ids) == 8 {
+			break
+		}
+		if !containsID(ids, c.ID) {
+			ids = append(ids, c.ID)
+		}
+	}
+	s.must(seat, EvPutBottom, PutBottomPayload{DiscardIDs: ids})
+}
+
+func (s *handSim) changeOrPass() {
+	for seat := 0; seat < 4; seat++ {
+		if seat == s.st.BottomOwnerSeat || s.st.FightPassMask&(1<<uint(seat)) != 0 {
+			continue
+		}
+		if !s.st.Trump.Locked && s.tryChange(seat) {
+			return
+		}
+		s.must(seat, EvCallPass, struct{}{})
+		return
+	}
+	s.t.Fatal("改主阶段没有可操作的座位")
+}
+
+func (s *handSim) tryChange(seat int) bool {
+	hand := s.st.Seats[seat].Hand
+	for _, j := range hand {
+		if !rules.IsBigJoker(j) && !rules.IsSmallJoker(j) {
+			continue
+		}
+		for a := 0; a < len(hand); a++ {
+			for b := a + 1; b < len(hand); b++ {
+				c1, c2 := hand[a], hand[b]
+				if c1.Rank != s.st.Trump.LevelRank || c1.Suit != c2.Suit || c1.Rank != c2.Rank {
+					continue
+				}
+				if checkDeclCards(&s.st, []rules.Card{j, c1, c2}) != nil {
+					continue
+				}
+				if _, err := rules.ValidateChangeTrump(s.st.Trump.LevelRank, j, c1, c2); err != nil {
+					continue
+				}
+				if s.apply(seat, EvChangeTrump, ChangeTrumpPayload{JokerID: j.ID, LevelIDs: []int{c1.ID, c2.ID}}) == nil {
+					s.change = true
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (s *handSim) play() {
+	seat := s.st.Trick.TurnSeat
+	hand := s.st.Seats[seat].Hand
+	t := s.st.Trump.Trump
+	if seat != s.st.Trick.LeaderSeat {
+		lead := s.st.Trick.Plays[s.st.Trick.LeaderSeat]
+		cands, err := rules.LegalFollows(hand, lead.Blocks, t, 1)
+		if err != nil || len(cands) == 0 {
+			s.t.Fatalf("%d号位没有合法跟牌: %v", seat, err)
+		}
+		s.must(seat, EvPlayCards, PlayCardsPayload{CardIDs: cardIDs(cands[0].Cards)})
+		return
+	}
+	// 先手：某门副牌有两张不同的单张时甩最大和最小的一张（成功与否由规则裁定）
+	for _, sc := range []rules.SuitClass{rules.SCS, rules.SCH, rules.SCC, rules.SCD} {
+		singles, err := rules.FindBlocksInHand(hand, t, sc, rules.BlockSingle, 0)
+		if err != nil || len(singles) < 3 {
+			continue
+		}
+		hi, lo := singles[0].Cards[0], singles[len(singles)-1].Cards[0]
+		if hi.Suit == lo.Suit && hi.Rank == lo.Rank {
+			continue
+		}
+		s.must(seat, EvPlayCards, PlayCardsPayload{CardIDs: []int{hi.ID, lo.ID}})
+		s.throw = true
+		return
+	}
+	cands, err := rules.LegalLeads(hand, t)
+	if err != nil || len(cands) == 0 {
+		s.t.Fatalf("%d号位没有合法先手: %v", seat, err)
+	}
+	s.must(seat, EvPlayCards, PlayCardsPayload{CardIDs: cardIDs(cands[0].Cards)})
+}
+
+func cardIDs(cards []rules.Card) []int {
+	out := make([]int, len(cards))
+	for i, c := range cards {
+		out[i] = c.ID
+	}
+	return out
+}
+
+func containsID(ids []int, id int) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+// roundTrip 导出 -> 导入 -> 再导出，两次导出应完全一致，导入后的重放结果应与模拟一致
+func roundTrip(t *testing.T, h HandHistory, want GameState) string {
+	t.Helper()
+	text, err := ExportHand(h)
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	parsed, err := ParseHand(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("导入失败: %v\n%s", err, text)
+	}
+	again, err := ExportHand(parsed)
+	if err != nil {
+		t.Fatalf("再次导出失败: %v", err)
+	}
+	if again != text {
+		t.Fatalf("再次导出与原牌谱不一致\n--- 原牌谱\n%s\n--- 再次导出\n%s", text, again)
+	}
+	got, err := parsed.Replay()
+	if err != nil {
+		t.Fatalf("重放失败: %v", err)
+	}
+	if got.Points != want.Points || got.RoundResultLabel != want.RoundResultLabel || got.NextStarterSeat != want.NextStarterSeat {
+		t.Fatalf("重放结果不一致: points %d/%d label %s/%s next %d/%d",
+			got.Points, want.Points, got.RoundResultLabel, want.RoundResultLabel, got.NextStarterSeat, want.NextStarterSeat)
+	}
+	return text
+}
+
+// findFullHand 找到一局同时出现定主、改主、甩牌且抠底得分的小局，返回其牌谱
+func findFullHand(t *testing.T) string {
+	t.Helper()
+	for seed := int64(1); seed <= 500; seed++ {
+		s := newHandSim(t, seed)
+		s.playRound()
+		text := roundTrip(t, s.hist, s.st)
+		if s.called && s.change && s.throw && s.st.BottomAward > 0 {
+			return text
+		}
+	}
+	t.Fatal("前 500 个种子中没有同时包含定主、改主、甩牌、抠底的小局")
+	return ""
+}
+
+func TestHandRoundTrip(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		s := newHandSim(t, seed)
+		for round := 0; round < 2 && s.st.Phase != PhaseGameOver; round++ {
+			if round > 0 {
+				s.nextRound(seed*100 + int64(round))
+			}
+			s.playRound()
+			roundTrip(t, s.hist, s.st)
+		}
+	}
+}
+
+func TestHandRoundTripFullFeatures(t *testing.T) {
+	text := findFullHand(t)
+	for _, kw := range []string{"\ncall ", "\nchange ", "\ndig ", "\nsettle "} {
+		if !strings.Contains(text, kw) {
+			t.Fatalf("牌谱中缺少 %q", strings.TrimSpace(kw))
+		}
+	}
+	if !strings.Contains(text, "seat 0 a%20b") || !strings.Contains(text, "seat 1 c%25d") {
+		t.Fatalf("uid 未转义:\n%s", text)
+	}
+}
+
+// lineIndex 第一个以 prefix 开头的行
+func lineIndex(lines []string, prefix string) int {
+	for i, l := range lines {
+		if strings.HasPrefix(l, prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestParseHandErrors(t *testing.T) {
+	text := findFullHand(t)
+
+	cases := []struct {
+		name   string
+		mutate func(lines []string) int // 返回被修改的行号（从1开始）
+		code   string
+	}{
+		{
+			name: "牌面与ID不符",
+			mutate: func(lines []string) int {
+				i := lineIndex(lines, "hand 0 ")
+				f := strings.Fields(lines[i])
+				face, id, _ := strings.Cut(f[2], "#")
+				wrong := "SA"
+				if face == wrong {
+					wrong = "HA"
+				}
+				f[2] = wrong + "#" + id
+				lines[i] = strings.Join(f, " ")
+				return i + 1
+			},
+			code: ErrHandSyntax.Code,
+		},
+		{
+			name: "出牌顺序与trick行不符",
+			mutate: func(lines []string) int {
+				i := lineIndex(lines, "trick 0 ")
+				order := strings.Fields(lines[i])[2:]
+				// 第二手改成第三个座位出牌
+				j := i + 1
+				for !strings.HasPrefix(lines[j], "play ") {
+					j++
+				}
+				j++
+				for !strings.HasPrefix(lines[j], "play ") {
+					j++
+				}
+				f := strings.Fields(lines[j])
+				f[1] = order[2]
+				lines[j] = strings.Join(f, " ")
+				return j + 1
+			},
+			code: ErrHandSyntax.Code,
+		},
+		{
+			name: "结算与重放不一致",
+			mutate: func(lines []string) int {
+				i := lineIndex(lines, "settle ")
+				f := strings.Fields(lines[i])
+				for k, kv := range f {
+					if strings.HasPrefix(kv, "points=") {
+						f[k] = "points=999"
+					}
+				}
+				lines[i] = strings.Join(f, " ")
+				return i + 1
+			},
+			code: ErrHandReplay.Code,
+		},
+		{
+			name: "首行不是格式头",
+			mutate: func(lines []string) int {
+				lines[0] = "upgrade-hand 2"
+				return 1
+			},
+			code: ErrHandSyntax.Code,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lines := strings.Split(text, "\n")
+			line := tc.mutate(lines)
+			_, err := ParseHand(strings.NewReader(strings.Join(lines, "\n")))
+			if err == nil {
+				t.Fatal("应当解析失败")
+			}
+			if err.Code != tc.code {
+				t.Fatalf("错误码 %s，期望 %s（%s）", err.Code, tc.code, err.Info)
+			}
+			if want := "第" + strconv.Itoa(line) + "行"; !strings.Contains(err.Info, want) {
+				t.Fatalf("错误信息 %q 中没有行号 %s", err.Info, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package game
+
+// NoticeCode 通知码：稳定、可机读，前端可据此做样式/动效；文案由 i18n 目录按连接语言渲染
+type NoticeCode string
+
+// NoticeParams 通知的结构化参数，常用 key：
+// - uid / target：玩家 uid；seat / nextSeat / fromSeat：座位号
+// - suit（rules.Suit）/ rank（rules.Rank）/ suitClass（rules.SuitClass）
+// - points / total / base / mul / count / round / rounds / team
+// - label：结算档位名称；callerDelta / defenderDelta：升级数；action：被撤回的事件类型；profile：规则方案名
+type NoticeParams map[string]any
+
+// Notice 一条结构化通知；一次事件可能产生多条（如出牌 -> 本墩结束 -> 抠底 -> 小局结束）
+type Notice struct {
+	Code   NoticeCode   `json:"code"`
+	Params NoticeParams `json:"params,omitempty"`
+}
+
+func NewNotice(code NoticeCode, params NoticeParams) Notice {
+	return Notice{Code: code, Params: params}
+}
+
+// ---------- 大厅 ----------
+const (
+	NtSeatSit         NoticeCode = "seat.sit"         // uid, seat
+	NtSeatLeave       NoticeCode = "seat.leave"       // uid, seat
+	NtDealAuto        NoticeCode = "deal.auto"        // 所有人已准备，自动发牌
+	NtDealForce       NoticeCode = "deal.force"       // 房主强制开局
+	NtDealManual      NoticeCode = "deal.manual"      // 手动发牌
+	NtSettingsUpdated NoticeCode = "settings.updated" // 房间设置已更新
+	NtSettingsProfile NoticeCode = "settings.profile" // profile（内置方案）
+	NtSettingsCustom  NoticeCode = "settings.custom"  // profile（自定义方案）
+)
+
+// ---------- 定主 / 扣底 / 改主攻主 ----------
+const (
+	NtCallPass        NoticeCode = "call.pass"         // seat
+	NtCallHard        NoticeCode = "call.hard"         // rank：无人定主，硬主
+	NtCallTrump       NoticeCode = "call.trump"        // seat, suit, rank
+	NtCallTrumpLocked NoticeCode = "call.trump_locked" // seat, suit, rank：定主并锁定花色
+	NtBottomDone      NoticeCode = "bottom.done"       // seat
+	NtBottomNoDig     NoticeCode = "bottom.no_dig"     // 打家攻主扣底，本小局打家不可挖底
+	NtFightPass       NoticeCode = "fight.pass"        // seat
+	NtFightChange     NoticeCode = "fight.change"      // seat, suit
+	NtFightAttack     NoticeCode = "fight.attack"      // seat
+	NtPlayStart       NoticeCode = "play.start"        // seat：进入出牌阶段，由该座位先手
+	NtFightClosed     NoticeCode = "fight.closed"      // seat：无人继续改/攻主，进入出牌阶段
+)
+
+// ---------- 出牌 / 结算 ----------
+const (
+	NtPlayCards     NoticeCode = "play.cards"        // seat
+	NtPlayThrowOK   NoticeCode = "play.throw_ok"     // seat
+	NtPlayThrowFail NoticeCode = "play.throw_fail"   // seat, count, suitClass：甩牌失败，只出了最小的一组
+	NtPlayPad       NoticeCode = "play.pad"          // seat：垫牌
+	NtTrickCaller   NoticeCode = "trick.caller"      // seat, points：坐家赢墩
+	NtTrickDefender NoticeCode = "trick.defender"    // seat, points, total：打家赢墩
+	NtDigAward      NoticeCode = "dig.award"         // base, mul, total
+	NtDigCallerWon  NoticeCode = "dig.caller_won"    // 末墩坐家赢，打家未能挖底
+	NtDigHardTrump  NoticeCode = "dig.hard_trump"    // 硬主不可挖底
+	NtDigAttacked   NoticeCode = "dig.attacked"      // 打家攻主后不可挖底
+	NtRoundEnd      NoticeCode = "round.end"         // points, label, callerDelta, defenderDelta, nextSeat
+	NtRoundSwap     NoticeCode = "round.swap"        // fromSeat, nextSeat
+	NtRoundNext     NoticeCode = "round.next"        // seat, round, nextSeat
+	NtMatchEnd      NoticeCode = "match.end"         // team, rounds
+	NtMatchRematch  NoticeCode = "match.rematch"     // seat
+	NtBadCallerSeat NoticeCode = "error.caller_seat" // seat：CallerSeat 非法，无法结算
+)
+
+// ---------- 房间（room 层） ----------
+const (
+	NtHostChanged     NoticeCode = "host.changed"      // uid
+	NtHostKicked      NoticeCode = "host.kicked"       // uid
+	NtHostKickedBot   NoticeCode = "host.kicked_bot"   // uid, seat：对局中被移出，机器人代打到整局结束
+	NtClockAuto       NoticeCode = "clock.auto"        // uid：操作超时，系统代打
+	NtUndoRequest     NoticeCode = "undo.request"      // uid, target, action
+	NtUndoRejected    NoticeCode = "undo.rejected"     // uid
+	NtUndoStale       NoticeCode = "undo.stale"        // 局面已变化，表决作废
+	NtUndoSeatChanged NoticeCode = "undo.seat_changed" // 座位已变化，无法悔棋
+	NtUndoDone        NoticeCode = "undo.done"         // target, action
+	NtSessionReplaced NoticeCode = "session.replaced"  // 同 uid 在其他位置登录（ws 层）
+)
@@ -0,0 +1,59 @@
+package game
+
+import (
+	"encoding/json"
+
+	"upgrade-lan/internal/game/rules"
+)
+
+// persistedState 落盘格式：公开字段随 GameState 序列化，json:"-" 的私有字段单独保存
+type persistedState struct {
+	State GameState `json:"state"`
+
+	Hands           [4][]rules.Card `json:"hands"`
+	Bottom          []rules.Card    `json:"bottom"`
+	CallPassMask    uint8           `json:"callPassMask"`
+	FightPassMask   uint8           `json:"fightPassMask"`
+	NextStarterSeat int             `json:"nextStarterSeat"`
+	DealSeed        rules.Seed      `json:"dealSeed"`
+}
+
+// MarshalFull 序列化完整 GameState（包括手牌、底牌等私有字段），仅用于服务端落盘/恢复
+func MarshalFull(st GameState) ([]byte, error) {
+	p := persistedState{
+		State:           st,
+		Bottom:          st.Bottom,
+		CallPassMask:    st.CallPassMask,
+		FightPassMask:   st.FightPassMask,
+		NextStarterSeat: st.NextStarterSeat,
+		DealSeed:        st.DealSeed,
+	}
+	for i := 0; i < 4; i++ {
+		p.Hands[i] = st.Seats[i].Hand
+	}
+	return json.Marshal(p)
+}
+
+// UnmarshalFull MarshalFull 的逆过程
+func UnmarshalFull(b []byte) (GameState, error) {
+	var p persistedState
+	if err := json.Unmarshal(b, &p); err != nil {
+		return GameState{}, err
+	}
+	st := p.State
+	for i := 0; i < 4; i++ {
+		st.Seats[i].Hand = p.Hands[i]
+		// 旧版本换座/离座时会把空出座位的队伍写错，队伍只由座位号决定
+		st.Seats[i].Team = TeamOfSeat(i)
+	}
+	st.Bottom = p.Bottom
+	st.CallPassMask = p.CallPassMask
+	st.FightPassMask = p.FightPassMask
+	st.NextStarterSeat = p.NextStarterSeat
+	st.DealSeed = p.DealSeed
+	// 旧存档没有规则方案
+	if len(st.Config.Tiers) == 0 {
+		st.Config = DefaultRoomConfig()
+	}
+	return st, nil
+}
@@ -8,13 +8,15 @@ import (
 type ReduceResult struct {
 	State   GameState
 	Changed bool
-	Notice  string
+	Notices []Notice      // 结构化通知，由 room 按连接语言渲染后下发
+	Events  []DomainEvent // 领域事件（按发生顺序），由 room 以 event 消息广播
 }
 
-func Reduce(st GameState, uid string, typ ClientEventType, payload any) (ReduceResult, *AppError) {
+// Reduce 处理核心：seeds 仅在发牌时取用一次
+func Reduce(st GameState, seeds SeedSource, uid string, typ ClientEventType, payload any) (ReduceResult, *AppError) {
 	switch st.Phase {
 	case PhaseLobby:
-		return reduceLobby(st, uid, typ, payload)
+		return reduceLobby(st, seeds, uid, typ, payload)
 	case PhaseCallTrump:
 		return reduceCallTrump(st, uid, typ, payload)
 	case PhaseBottom:
@@ -24,13 +26,15 @@ func Reduce(st GameState, uid string, typ ClientEventType, payload any) (ReduceR
 	case PhasePlayTrick:
 		return reducePlayTrick(st, uid, typ, payload)
 	case PhaseRoundSettle:
-		return reduceStartNextRound(st, uid, typ, payload)
+		return reduceStartNextRound(st, seeds, uid, typ, payload)
+	case PhaseGameOver:
+		return reduceGameOver(st, uid, typ, payload)
 	default:
-		return ReduceResult{State: st, Changed: false}, ErrStateWrongPhase.WithInfof("非法游戏阶段 %s", st.Phase)
+		return ReduceResult{State: st, Changed: false}, ErrSystem.WithInfof("未知的游戏阶段 %s", st.Phase)
 	}
 }
 
-func reduceLobby(st GameState, uid string, typ ClientEventType, payload any) (ReduceResult, *AppError) {
+func reduceLobby(st GameState, seeds SeedSource, uid string, typ ClientEventType, payload any) (ReduceResult, *AppError) {
 	switch typ {
 	case EvSit:
 		p := payload.(SitPayload)
@@ -41,23 +45,22 @@ func reduceLobby(st GameState, uid string, typ ClientEventType, payload any) (Re
 		// 如果 uid 已经坐在别处，先清掉旧座位
 		for i := 0; i < 4; i++ {
 			if st.Seats[i].UID == uid && i != p.Seat {
-				st.Seats[i] = SeatState{}
-				st.Seats[i].Team = p.Seat % 2
+				st.Seats[i] = emptySeat(i)
 			}
 		}
 		seat.UID = uid
 		seat.Online = true
 		seat.Ready = false
 		st.Version++
-		return ReduceResult{State: st, Changed: true, Notice: fmt.Sprintf("玩家%s已坐入%d号位", uid, p.Seat)}, nil
+		return ReduceResult{State: st, Changed: true, Notices: []Notice{NewNotice(NtSeatSit, NoticeParams{"uid": uid, "seat": p.Seat})}}, nil
 
 	case EvLeave:
 		// uid 离开自己座位
 		for i := 0; i < 4; i++ {
 			if st.Seats[i].UID == uid {
-				st.Seats[i] = SeatState{}
+				st.Seats[i] = emptySeat(i)
 				st.Version++
-				return ReduceResult{State: st, Changed: true, Notice: fmt.Sprintf("玩家%s已离开%d号位", uid, i)}, nil
+				return ReduceResult{State: st, Changed: true, Notices: []Notice{NewNotice(NtSeatLeave, NoticeParams{"uid": uid, "seat": i})}}, nil
 			}
 		}
 		return ReduceResult{State: st}, ErrStateNotSeated.WithInfof("当前还未就坐")
@@ -72,9 +75,10 @@ func reduceLobby(st GameState, uid string, typ ClientEventType, payload any) (Re
 
 				// 自动 start：4 人都 ready
 				if allReady(&st) {
-					startDeal(&st)
+					startDeal(&st, seeds.NextSeed())
 					rr.State = st
-					rr.Notice = "所有人已准备，系统已自动发牌"
+					rr.Notices = []Notice{NewNotice(NtDealAuto, nil)}
+					rr.Events = []DomainEvent{roundDealt(&st)}
 				}
 				return rr, nil
 			}
@@ -82,26 +86,66 @@ func reduceLobby(st GameState, uid string, typ ClientEventType, payload any) (Re
 		return ReduceResult{State: st}, ErrStateNotSeated.WithInfof("当前还未就坐")
 
 	case EvStart:
-		// 手动 start：仅当 4 人都 ready（可限制房主）
-		if !allReady(&st) {
+		// 手动 start：仅当 4 人都 ready；强制开局只要求 4 人入座（房主限制在 room 层）
+		p, _ := payload.(StartPayload)
+		if p.Force {
+			for i := 0; i < 4; i++ {
+				if st.Seats[i].UID == "" {
+					return ReduceResult{State: st}, ErrStateNotSeated.WithInfof("%d号位还没有玩家", i)
+				}
+			}
+		} else if !allReady(&st) {
 			return ReduceResult{State: st}, ErrStateNotReady.WithInfof("还有人没准备")
 		}
-		startDeal(&st)
-		return ReduceResult{State: st, Changed: true, Notice: "已手动发牌"}, nil
+		startDeal(&st, seeds.NextSeed())
+		events := []DomainEvent{roundDealt(&st)}
+		if p.Force {
+			return ReduceResult{State: st, Changed: true, Notices: []Notice{NewNotice(NtDealForce, nil)}, Events: events}, nil
+		}
+		return ReduceResult{State: st, Changed: true, Notices: []Notice{NewNotice(NtDealManual, nil)}, Events: events}, nil
+
+	case EvSettings:
+		p := payload.(SettingsPayload)
+		notice := NewNotice(NtSettingsUpdated, nil)
+		if p.HideRecord != nil {
+			st.HideRecord = *p.HideRecord
+		}
+		if p.DisableUndo != nil {
+			st.DisableUndo = *p.DisableUndo
+		}
+		if p.Timers != nil {
+			t := *p.Timers
+			st.Timers = &t
+		}
+		if p.Profile != "" {
+			st.Config, _ = RoomPreset(p.Profile)
+			notice = NewNotice(NtSettingsProfile, NoticeParams{"profile": st.Config.Profile})
+		}
+		if p.Config != nil {
+			st.Config = p.Config.clone()
+			notice = NewNotice(NtSettingsCustom, NoticeParams{"profile": st.Config.Profile})
+		}
+		st.Version++
+		return ReduceResult{State: st, Changed: true, Notices: []Notice{notice}}, nil
 
 	default:
-		return ReduceResult{State: st}, ErrUnknownEvent.WithInfof("未知Phase状态 %s", st.Phase)
+		return ReduceResult{State: st}, ErrStateWrongPhase.WithInfof("大厅阶段不允许事件 %s", typ)
 	}
 }
 
-func startDeal(st *GameState) {
-	// 进入dealing
-	st.Phase = PhaseDealing
-
-	// 生成两副牌并洗牌发牌
+func startDeal(st *GameState, seed rules.Seed) {
+	// 生成两副牌并洗牌发牌（记录本小局种子，便于复现）
 	deck := rules.NewDoubleDeck()
-	rules.ShuffleInPlace(deck)
+	rules.ShuffleInPlace(deck, seed)
 	hands, bottom := rules.Deal(deck)
+	dealCards(st, seed, hands, bottom)
+}
+
+// dealCards 写入手牌、底牌并进入定主；导入牌谱时直接使用记录的手牌
+func dealCards(st *GameState, seed rules.Seed, hands [4][]rules.Card, bottom []rules.Card) {
+	// 进入dealing
+	st.Phase = PhaseDealing
+	st.DealSeed = seed
 
 	// 写入座位手牌
 	for i := 0; i < 4; i++ {
@@ -149,6 +193,7 @@ func startDeal(st *GameState) {
 		Locked:     false,
 		CallerSeat: -1,
 	}
+	st.Declarations = nil
 	st.Points = 0
 	st.Record = Record{}
 
@@ -211,11 +256,12 @@ func reduceCallTrump(st GameState, uid string, typ ClientEventType, payload any)
 				BiggerSeat: -1,
 			}
 			st.Version++
-			notice := fmt.Sprintf("无人定主，本小局硬主，级牌为%s", st.Trump.LevelRank)
-			return ReduceResult{State: st, Changed: true, Notice: notice}, nil
+			notice := NewNotice(NtCallHard, NoticeParams{"rank": st.Trump.LevelRank})
+			event := NoTrump{Rank: st.Trump.LevelRank, LeaderSeat: st.CallerSeat}
+			return ReduceResult{State: st, Changed: true, Notices: []Notice{notice}, Events: []DomainEvent{event}}, nil
 		}
 
-		return ReduceResult{State: st, Changed: true, Notice: fmt.Sprintf("%d号位不定主", seat)}, nil
+		return ReduceResult{State: st, Changed: true, Notices: []Notice{NewNotice(NtCallPass, NoticeParams{"seat": seat})}}, nil
 
 	case EvCallTrump:
 		p := payload.(CallTrumpPayload)
@@ -232,6 +278,9 @@ func reduceCallTrump(st GameState, uid string, typ ClientEventType, payload any)
 			}
 			levelCards = append(levelCards, c)
 		}
+		if err := checkDeclCards(&st, append([]rules.Card{joker}, levelCards...)); err != nil {
+			return ReduceResult{State: st}, err
+		}
 		// 校验定主规则
 		team := st.Seats[seat].Team
 		teamLevel := st.Teams[team].LevelRank
@@ -246,6 +295,7 @@ func reduceCallTrump(st GameState, uid string, typ ClientEventType, payload any)
 		st.Trump.Locked = locked
 		st.Trump.CallerSeat = seat
 		st.CallerSeat = seat
+		recordDeclaration(&st, seat, DeclCall, append([]rules.Card{joker}, levelCards...))
 
 		// 修改每张牌的牌域，手牌按“本小局最终级牌 + 主花色”重排
 		refreshCardsSuitClass(&st)
@@ -253,14 +303,22 @@ func reduceCallTrump(st GameState, uid string, typ ClientEventType, payload any)
 
 		// 进入下一阶段：坐家收底牌、重扣底牌
 		enterBottomPhase(&st, seat)
-		notice := fmt.Sprintf("成功定主，主牌为%s，级牌为%s，请扣底牌", st.Trump.Suit, st.Trump.LevelRank)
+		code := NtCallTrump
 		if locked {
-			notice = fmt.Sprintf("成功定主，主牌为%s（已锁定），级牌为%s，请扣底牌", st.Trump.Suit, st.Trump.LevelRank)
+			code = NtCallTrumpLocked
+		}
+		notice := NewNotice(code, NoticeParams{"seat": seat, "suit": st.Trump.Suit, "rank": st.Trump.LevelRank})
+		event := TrumpCalled{
+			Seat:   seat,
+			Suit:   st.Trump.Suit,
+			Rank:   st.Trump.LevelRank,
+			Locked: locked,
+			Cards:  publicCards(append([]rules.Card{joker}, levelCards...)),
 		}
-		return ReduceResult{State: st, Changed: true, Notice: notice}, nil
+		return ReduceResult{State: st, Changed: true, Notices: []Notice{notice}, Events: []DomainEvent{event}}, nil
 
 	default:
-		return ReduceResult{State: st}, ErrUnknownEvent.WithInfof("非法事件 %s", typ)
+		return ReduceResult{State: st}, ErrStateWrongPhase.WithInfof("定主阶段不允许事件 %s", typ)
 	}
 }
 
@@ -314,18 +372,21 @@ func reduceBottom(st GameState, uid string, typ ClientEventType, payload any) (R
 				BiggerSeat: -1,
 			}
 			st.Version++
-			notice := fmt.Sprintf("玩家%d完成扣牌", seat)
+			notices := []Notice{NewNotice(NtBottomDone, NoticeParams{"seat": seat})}
 			if !inCallerGroup(&st, seat) {
-				notice = notice + "，本回合打家不可挖底"
+				notices = append(notices, NewNotice(NtBottomNoDig, nil))
 			}
-			notice = fmt.Sprintf("%s。进入出牌阶段，由%d号位先手", notice, st.CallerSeat)
-			return ReduceResult{State: st, Changed: true, Notice: notice}, nil
+			notices = append(notices, NewNotice(NtPlayStart, NoticeParams{"seat": st.CallerSeat}))
+			events := []DomainEvent{BottomBuried{Seat: seat, Count: st.BottomCount}, PlayStarted{LeaderSeat: st.CallerSeat}}
+			return ReduceResult{State: st, Changed: true, Notices: notices, Events: events}, nil
 		}
 		st = enterTrumpFight(st)
-		return ReduceResult{State: st, Changed: true, Notice: fmt.Sprintf("玩家%d完成扣牌", seat)}, nil
+		notices := []Notice{NewNotice(NtBottomDone, NoticeParams{"seat": seat})}
+		events := []DomainEvent{BottomBuried{Seat: seat, Count: st.BottomCount}}
+		return ReduceResult{State: st, Changed: true, Notices: notices, Events: events}, nil
 
 	default:
-		return ReduceResult{State: st}, ErrUnknownEvent.WithInfof("非法事件 %s", typ)
+		return ReduceResult{State: st}, ErrStateWrongPhase.WithInfof("扣底阶段不允许事件 %s", typ)
 	}
 }
 
@@ -359,7 +420,7 @@ func reduceTrumpFight(st GameState, uid string, typ ClientEventType, payload any
 		st.FightPassMask |= bit
 		st.FightPassCount++
 		st.Version++
-		notice := fmt.Sprintf("玩家%d已选择跳过", seat)
+		notices := []Notice{NewNotice(NtFightPass, NoticeParams{"seat": seat})}
 		// 其余三位都跳过，则正式进入出牌阶段
 		if st.FightPassCount >= 3 {
 			st.Phase = PhasePlayTrick
@@ -369,10 +430,11 @@ func reduceTrumpFight(st GameState, uid string, typ ClientEventType, payload any
 				BiggerSeat: -1,
 			}
 			st.Version++
-			notice = fmt.Sprintf("%s。无人继续改/攻主，进入出牌阶段，由%d号位先手", notice, st.CallerSeat)
-			return ReduceResult{State: st, Changed: true, Notice: notice}, nil
+			notices = append(notices, NewNotice(NtFightClosed, NoticeParams{"seat": st.CallerSeat}))
+			events := []DomainEvent{PlayStarted{LeaderSeat: st.CallerSeat}}
+			return ReduceResult{State: st, Changed: true, Notices: notices, Events: events}, nil
 		}
-		return ReduceResult{State: st, Changed: true, Notice: notice}, nil
+		return ReduceResult{State: st, Changed: true, Notices: notices}, nil
 
 	case EvChangeTrump:
 		if st.Trump.Locked {
@@ -392,6 +454,9 @@ func reduceTrumpFight(st GameState, uid string, typ ClientEventType, payload any
 		if !ok {
 			return ReduceResult{State: st}, ErrRuleIllegalTrump.WithInfo("手牌中无此牌")
 		}
+		if err := checkDeclCards(&st, []rules.Card{joker, c1, c2}); err != nil {
+			return ReduceResult{State: st}, err
+		}
 		// 改主规则校验
 		trumpSuit, err := rules.ValidateChangeTrump(st.Trump.LevelRank, joker, c1, c2)
 		if err != nil {
@@ -400,12 +465,14 @@ func reduceTrumpFight(st GameState, uid string, typ ClientEventType, payload any
 		// 改主成功：只改主花色，不改 LevelRank / CallerSeat
 		st.Trump.HasTrumpSuit = true
 		st.Trump.Suit = trumpSuit
+		recordDeclaration(&st, seat, DeclChange, []rules.Card{joker, c1, c2})
 		refreshCardsSuitClass(&st)
 		sortAllHands(&st)
 		// 改主者成为 bottomOwner，拿当前底牌并扣底
 		enterBottomPhase(&st, seat)
-		notice := fmt.Sprintf("%d号位改主成功，变为花色%s", seat, st.Trump.Suit)
-		return ReduceResult{State: st, Changed: true, Notice: notice}, nil
+		notice := NewNotice(NtFightChange, NoticeParams{"seat": seat, "suit": st.Trump.Suit})
+		event := TrumpChanged{Seat: seat, Suit: st.Trump.Suit, Cards: publicCards([]rules.Card{joker, c1, c2})}
+		return ReduceResult{State: st, Changed: true, Notices: []Notice{notice}, Events: []DomainEvent{event}}, nil
 
 	case EvAttackTrump:
 		// 初步校验
@@ -418,6 +485,9 @@ func reduceTrumpFight(st GameState, uid string, typ ClientEventType, payload any
 		if !ok {
 			return ReduceResult{State: st}, ErrRuleIllegalTrump.WithInfo("手牌中无此牌")
 		}
+		if err := checkDeclCards(&st, []rules.Card{j1, j2}); err != nil {
+			return ReduceResult{State: st}, err
+		}
 		// 攻主规则校验
 		err := rules.ValidateAttackTrump(j1, j2)
 		if err != nil {
@@ -427,22 +497,24 @@ func reduceTrumpFight(st GameState, uid string, typ ClientEventType, payload any
 		st.Trump.HasTrumpSuit = false
 		st.Trump.Suit = rules.SuitAttack
 		st.Trump.Locked = true
+		recordDeclaration(&st, seat, DeclAttack, []rules.Card{j1, j2})
 		refreshCardsSuitClass(&st)
 		sortAllHands(&st)
 		// 攻主者成为 bottomOwner，拿底扣底，随后将直接进入游戏
 		enterBottomPhase(&st, seat)
-		notice := fmt.Sprintf("玩家%d攻主成功，本小局硬主", seat)
-		return ReduceResult{State: st, Changed: true, Notice: notice}, nil
+		notice := NewNotice(NtFightAttack, NoticeParams{"seat": seat})
+		event := TrumpAttacked{Seat: seat, Cards: publicCards([]rules.Card{j1, j2})}
+		return ReduceResult{State: st, Changed: true, Notices: []Notice{notice}, Events: []DomainEvent{event}}, nil
 
 	default:
-		return ReduceResult{State: st}, ErrUnknownEvent.WithInfof("非法事件 %s", typ)
+		return ReduceResult{State: st}, ErrStateWrongPhase.WithInfof("改主/攻主阶段不允许事件 %s", typ)
 	}
 }
 
 func reducePlayTrick(st GameState, uid string, typ ClientEventType, payload any) (ReduceResult, *AppError) {
 	// 合法校验
 	if st.Phase != PhasePlayTrick {
-		return ReduceResult{State: st}, ErrUnknownEvent.WithInfo("当前不在出牌阶段")
+		return ReduceResult{State: st}, ErrStateWrongPhase.WithInfo("当前不在出牌阶段")
 	}
 	seat, err := seatIndexByUID(&st, uid)
 	if err != nil {
@@ -484,26 +556,23 @@ func reducePlayTrick(st GameState, uid string, typ ClientEventType, payload any)
 		st.Seats[seat].HandCount = len(st.Seats[seat].Hand)
 		// 更新 trick
 		st.Trick.Plays[seat] = &currentMove
-		notice := fmt.Sprintf("玩家%d已出牌", seat)
-		if currentMove.Info != "" {
-			notice += fmt.Sprintf("【%s】", currentMove.Info)
-		}
+		notices := []Notice{playNotice(&st, &currentMove, len(selected))}
+		events := playEvents(&st, &currentMove)
 		// 如果本墩已打满，则回合结算并设置下一墩先手/turnSeat
 		if isTrickComplete(&st.Trick) {
-			settleNotice := settleTrickEnd(&st)
-			if settleNotice != "" {
-				notice += "，" + settleNotice
-			}
+			n, e := settleTrickEnd(&st)
+			notices = append(notices, n...)
+			events = append(events, e...)
 			st.Version++
-			return ReduceResult{State: st, Changed: true, Notice: notice}, nil
+			return ReduceResult{State: st, Changed: true, Notices: notices, Events: events}, nil
 		}
 		// 否则轮到下家
 		st.Trick.TurnSeat = (seat + 1) % 4
 		st.Version++
-		return ReduceResult{State: st, Changed: true, Notice: notice}, nil
+		return ReduceResult{State: st, Changed: true, Notices: notices, Events: events}, nil
 
 	default:
-		return ReduceResult{State: st}, ErrUnknownEvent.WithInfof("非法事件 %s", typ)
+		return ReduceResult{State: st}, ErrStateWrongPhase.WithInfof("出牌阶段不允许事件 %s", typ)
 	}
 }
 
@@ -660,7 +729,47 @@ func followTrick(st *GameState, seat int, selected []rules.Card) (PlayedMove, *A
 	return currentMove, nil
 }
 
-func settleTrickEnd(st *GameState) string {
+// playNotice 出牌通知；甩牌结果以本次先手的 Trick.Throw 为准（selected 为原计划出牌张数）
+func playNotice(st *GameState, mv *PlayedMove, selected int) Notice {
+	seat := mv.Seat
+	switch {
+	case seat == st.Trick.LeaderSeat && st.Trick.Throw != nil && st.Trick.Throw.ThrowOK:
+		return NewNotice(NtPlayThrowOK, NoticeParams{"seat": seat})
+	case seat == st.Trick.LeaderSeat && st.Trick.Throw != nil:
+		return NewNotice(NtPlayThrowFail, NoticeParams{"seat": seat, "count": selected, "suitClass": mv.SuitClass})
+	case seat != st.Trick.LeaderSeat && mv.Info != "":
+		return NewNotice(NtPlayPad, NoticeParams{"seat": seat})
+	default:
+		return NewNotice(NtPlayCards, NoticeParams{"seat": seat})
+	}
+}
+
+// playEvents 出牌事件（在 settleTrickEnd 之前调用，Trick.Throw 仍是本墩先手的甩牌结果）
+func playEvents(st *GameState, mv *PlayedMove) []DomainEvent {
+	lead := mv.Seat == st.Trick.LeaderSeat
+	throw := st.Trick.Throw
+	played := CardsPlayed{
+		Seat:      mv.Seat,
+		Trick:     st.TrickIndex,
+		Lead:      lead,
+		Throw:     lead && throw != nil && throw.ThrowOK,
+		Padding:   !lead && mv.Info != "",
+		SuitClass: mv.SuitClass,
+		Cards:     append([]rules.Card(nil), mv.Cards...),
+	}
+	if lead && throw != nil && !throw.ThrowOK {
+		failed := ThrowFailed{
+			Seat:      mv.Seat,
+			SuitClass: mv.SuitClass,
+			Intended:  append([]rules.Card(nil), throw.IntentMove.Cards...),
+			Played:    played.Cards,
+		}
+		return []DomainEvent{failed, played}
+	}
+	return []DomainEvent{played}
+}
+
+func settleTrickEnd(st *GameState) ([]Notice, []DomainEvent) {
 	tr := &st.Trick
 	if tr.BiggerSeat < 0 {
 		tr.BiggerSeat = tr.LeaderSeat
@@ -692,31 +801,38 @@ func settleTrickEnd(st *GameState) string {
 	}
 	tr.Throw = nil
 
+	events := []DomainEvent{TrickWon{
+		Trick:         st.TrickIndex,
+		Seat:          winner,
+		CallerSide:    inCallerGroup(st, winner),
+		Points:        points,
+		DefenderTotal: st.Points,
+	}}
 	st.TrickIndex++
-	notice := ""
+	var notices []Notice
 	if inCallerGroup(st, winner) {
-		notice = fmt.Sprintf("本墩结束，赢家:%d号位（坐家），共跑分:%d，打家不得分", winner, points)
+		notices = append(notices, NewNotice(NtTrickCaller, NoticeParams{"seat": winner, "points": points}))
 	} else {
-		notice = fmt.Sprintf("本墩结束，赢家:%d号位（打家），共吃分:%d，打家累计分:%d", winner, points, st.Points)
+		notices = append(notices, NewNotice(NtTrickDefender, NoticeParams{"seat": winner, "points": points, "total": st.Points}))
 	}
 
 	// 末墩抠底：在“所有人手牌为空”时触发
 	if isLastTrickAfterThisTrick(st) {
 		st.Trick.TurnSeat = -1
-		digNotice := settleDigBottom(st, winner)
-		notice += "。" + digNotice
-		roundNotice := settleRoundEnd(st)
-		if roundNotice != "" {
-			notice += "。" + roundNotice
-		}
+		n, e := settleDigBottom(st, winner)
+		notices = append(notices, n...)
+		events = append(events, e...)
+		n, e = settleRoundEnd(st)
+		notices = append(notices, n...)
+		events = append(events, e...)
 	}
 
-	return notice
+	return notices, events
 }
 
-func settleDigBottom(st *GameState, winner int) string {
+func settleDigBottom(st *GameState, winner int) ([]Notice, []DomainEvent) {
 	if st.BottomRevealed { // 幂等：防重复触发
-		return ""
+		return nil, nil
 	}
 	st.BottomRevealed = true
 
@@ -725,7 +841,7 @@ func settleDigBottom(st *GameState, winner int) string {
 	if winner >= 0 && winner < 4 {
 		if pm := st.Trick.LastPlays[winner]; pm != nil {
 			if len(pm.Blocks) > 0 && len(pm.Blocks[0]) > 0 {
-				mul = rules.DigMultiplierByWinnerMove(pm.Blocks[0][0].Type)
+				mul = st.Config.Dig.Multiplier(pm.Blocks[0][0])
 			}
 		}
 	}
@@ -741,29 +857,39 @@ func settleDigBottom(st *GameState, winner int) string {
 		st.BottomAward = 0
 	}
 	st.BottomReveal = append([]rules.Card(nil), st.Bottom...)
+	event := BottomDug{
+		Seat:   winner,
+		Cards:  append([]rules.Card(nil), st.Bottom...),
+		Base:   base,
+		Mul:    mul,
+		Award:  st.BottomAward,
+		Dug:    dig,
+		Reason: reason,
+	}
 	if !dig {
-		return reason
+		return []Notice{NewNotice(reason, nil)}, []DomainEvent{event}
 	}
-	return fmt.Sprintf("末墩抠底，底牌分=%d×%d，打家累计分=%d", base, mul, st.Points)
+	return []Notice{NewNotice(NtDigAward, NoticeParams{"base": base, "mul": mul, "total": st.Points})}, []DomainEvent{event}
 }
 
-func settleRoundEnd(st *GameState) string {
-	if st.Phase == PhaseRoundSettle {
-		return ""
+func settleRoundEnd(st *GameState) ([]Notice, []DomainEvent) {
+	if st.Phase == PhaseRoundSettle || st.Phase == PhaseGameOver {
+		return nil, nil
 	}
 	out := computeRoundOutcome(st)
 
 	// callerTeam 以 GameState.CallerSeat 所在队为准（硬主也成立）
 	cs := st.CallerSeat
 	if cs < 0 || cs > 3 {
-		return fmt.Sprintf("Fatal! CallerSeat非法取值:%d", cs)
+		return []Notice{NewNotice(NtBadCallerSeat, NoticeParams{"seat": cs})}, nil
 	}
 	callerTeam := st.Seats[cs].Team
 	defTeam := 1 - callerTeam
 
 	// 写入升级
-	st.Teams[callerTeam].LevelRank = rules.AddRank(st.Teams[callerTeam].LevelRank, out.CallerDelta)
-	st.Teams[defTeam].LevelRank = rules.AddRank(st.Teams[defTeam].LevelRank, out.DefenderDelta)
+	var callerDone, defDone bool
+	st.Teams[callerTeam].LevelRank, callerDone = rules.AddRank(st.Teams[callerTeam].LevelRank, out.CallerDelta)
+	st.Teams[defTeam].LevelRank, defDone = rules.AddRank(st.Teams[defTeam].LevelRank, out.DefenderDelta)
 	// 写入下一局先手
 	st.NextStarterSeat = out.NextStarterSeat
 	st.CallMode = CallModeOrdered
@@ -777,51 +903,61 @@ func settleRoundEnd(st *GameState) string {
 	st.Phase = PhaseRoundSettle
 
 	// 注意：这里不清 Points/Trick 等，让前端还能看到本局结果
-	notice := fmt.Sprintf(
-		"小局结束：打家得分=%d，结果=%s，坐家+%d 打家+%d，下一局先手定主权=玩家%d（需其点击开始下一局）",
-		st.RoundPointsFinal, st.RoundResultLabel, st.CallerDelta, st.DefenderDelta, st.NextStarterSeat,
-	)
-	if st.Points >= 80 {
-		notice += fmt.Sprintf("（换坐：叫主起点从%d号位顺延到%d号位）", st.CallerSeat, st.NextStarterSeat)
-	}
-	return notice
+	notices := []Notice{NewNotice(NtRoundEnd, NoticeParams{
+		"points":        st.RoundPointsFinal,
+		"label":         st.RoundResultLabel,
+		"callerDelta":   st.CallerDelta,
+		"defenderDelta": st.DefenderDelta,
+		"nextSeat":      st.NextStarterSeat,
+	})}
+	if st.Points >= st.Config.SwapPoints {
+		notices = append(notices, NewNotice(NtRoundSwap, NoticeParams{"fromSeat": st.CallerSeat, "nextSeat": st.NextStarterSeat}))
+	}
+	events := []DomainEvent{RoundSettled{
+		Round:           st.RoundIndex,
+		Points:          st.RoundPointsFinal,
+		Label:           st.RoundResultLabel,
+		CallerTeam:      callerTeam,
+		CallerDelta:     st.CallerDelta,
+		DefenderDelta:   st.DefenderDelta,
+		Levels:          [2]rules.Rank{st.Teams[0].LevelRank, st.Teams[1].LevelRank},
+		NextStarterSeat: st.NextStarterSeat,
+	}}
+
+	// 某队打过 A：整局结束
+	if callerDone || defDone {
+		winner := callerTeam
+		if defDone {
+			winner = defTeam
+		}
+		st.Match = &MatchResult{
+			WinnerTeam:  winner,
+			Rounds:      st.RoundIndex + 1,
+			FinalLevels: [2]rules.Rank{st.Teams[0].LevelRank, st.Teams[1].LevelRank},
+		}
+		st.Phase = PhaseGameOver
+		notices = append(notices, NewNotice(NtMatchEnd, NoticeParams{"team": winner, "rounds": st.Match.Rounds}))
+		events = append(events, MatchOver{WinnerTeam: winner, Rounds: st.Match.Rounds, FinalLevels: st.Match.FinalLevels})
+	}
+	return notices, events
 }
 
 func computeRoundOutcome(st *GameState) RoundOutcome {
 	p := st.Points
-	callerSeat := st.CallerSeat
-	nextSeat := callerSeat
-	if p >= 80 {
-		nextSeat = (callerSeat + 1) % 4
-	}
-	// 0 分光头必须单独判定，避免被 <40 吞掉
-	if p == 0 {
-		return RoundOutcome{"光头", 3, 0, nextSeat} // nextSeat 在 p>=80? 不会，0<80，因此仍是 callerSeat
-	}
-	if p >= 200 {
-		return RoundOutcome{"满分", 0, 3, nextSeat}
+	nextSeat := st.CallerSeat
+	if p >= st.Config.SwapPoints {
+		nextSeat = (st.CallerSeat + 1) % 4
 	}
-	if p >= 160 {
-		return RoundOutcome{"大胜", 0, 2, nextSeat}
-	}
-	if p >= 120 {
-		return RoundOutcome{"过大关", 0, 1, nextSeat}
-	}
-	if p >= 80 {
-		return RoundOutcome{"换坐", 0, 0, nextSeat}
-	} // 仅让先手
-	if p >= 40 {
-		return RoundOutcome{"过小关", 1, 0, nextSeat}
-	}
-	return RoundOutcome{"不过小关", 2, 0, nextSeat} // 1–39
+	t := st.Config.Outcome(p)
+	return RoundOutcome{t.Label, t.CallerDelta, t.DefenderDelta, nextSeat}
 }
 
-func reduceStartNextRound(st GameState, uid string, typ ClientEventType, payload any) (ReduceResult, *AppError) {
+func reduceStartNextRound(st GameState, seeds SeedSource, uid string, typ ClientEventType, payload any) (ReduceResult, *AppError) {
 	if typ != EvStartNextRound {
-		return ReduceResult{State: st}, ErrUnknownEvent.WithInfof("非法事件 %s", typ)
+		return ReduceResult{State: st}, ErrStateWrongPhase.WithInfof("小局结算阶段不允许事件 %s", typ)
 	}
 	if st.Phase != PhaseRoundSettle {
-		return ReduceResult{State: st}, ErrUnknownEvent.WithInfo("当前不在小局结算阶段")
+		return ReduceResult{State: st}, ErrStateWrongPhase.WithInfo("当前不在小局结算阶段")
 	}
 	seat, err := seatIndexByUID(&st, uid)
 	if err != nil {
@@ -861,7 +997,64 @@ func reduceStartNextRound(st GameState, uid string, typ ClientEventType, payload
 
 	// 发牌
 	st.Phase = PhaseDealing
-	startDeal(&st)
-	notice := fmt.Sprintf("玩家%d开始下一小局（第%d局），%d号位优先定主", seat, st.RoundIndex, st.CallerSeat)
-	return ReduceResult{State: st, Changed: true, Notice: notice}, nil
+	startDeal(&st, seeds.NextSeed())
+	notice := NewNotice(NtRoundNext, NoticeParams{"seat": seat, "round": st.RoundIndex, "nextSeat": st.CallerSeat})
+	return ReduceResult{State: st, Changed: true, Notices: []Notice{notice}, Events: []DomainEvent{roundDealt(&st)}}, nil
+}
+
+func reduceGameOver(st GameState, uid string, typ ClientEventType, payload any) (ReduceResult, *AppError) {
+	switch typ {
+	case EvStartNextRound:
+		return ReduceResult{State: st}, ErrStateWrongPhase.WithInfo("整局已结束，请选择再来一局")
+	case EvRematch:
+		seat, err := seatIndexByUID(&st, uid)
+		if err != nil {
+			return ReduceResult{State: st}, err
+		}
+		// ---- 重开整局：级牌回到 2，座位保留，回到大厅重新准备 ----
+		st.Teams[0].LevelRank = rules.R2
+		st.Teams[1].LevelRank = rules.R2
+		st.Match = nil
+		st.RoundIndex = 0
+		st.NextStarterSeat = 0
+		st.CallMode = CallModeRace
+		st.CallerSeat = -1
+		st.CallTurnSeat = -1
+		st.CallPassMask = 0
+		st.CallPassCount = 0
+		st.FightPassMask = 0
+		st.FightPassCount = 0
+
+		st.Points = 0
+		st.RoundPointsFinal = 0
+		st.RoundResultLabel = ""
+		st.CallerDelta = 0
+		st.DefenderDelta = 0
+
+		st.TrickIndex = 0
+		st.Trick = TrickState{}
+		st.Record = Record{}
+		st.BottomOwnerSeat = -1
+		st.BottomCount = 0
+		st.Bottom = nil
+		st.BottomRevealed = false
+		st.BottomReveal = nil
+		st.BottomPoints = 0
+		st.BottomMul = 0
+		st.BottomAward = 0
+		st.Trump = TrumpState{CallerSeat: -1}
+		st.Declarations = nil
+
+		for i := 0; i < 4; i++ {
+			st.Seats[i].Ready = false
+			st.Seats[i].Hand = nil
+			st.Seats[i].HandCount = 0
+		}
+		st.Phase = PhaseLobby
+		st.Version++
+		notice := NewNotice(NtMatchRematch, NoticeParams{"seat": seat})
+		return ReduceResult{State: st, Changed: true, Notices: []Notice{notice}}, nil
+	default:
+		return ReduceResult{State: st}, ErrStateWrongPhase.WithInfof("整局已结束，不允许事件 %s", typ)
+	}
 }
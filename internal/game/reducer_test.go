@@ -0,0 +1,42 @@
+package game
+
+import "testing"
+
+// 换座、离座后空出的座位仍属于座位号对应的队伍
+func TestLobbySeatTeams(t *testing.T) {
+	type step struct {
+		uid  string
+		typ  ClientEventType
+		seat int
+	}
+	cases := []struct {
+		name  string
+		steps []step
+	}{
+		{"换到对方队伍的座位", []step{{"a", EvSit, 0}, {"a", EvSit, 1}}},
+		{"换到同队座位", []step{{"a", EvSit, 1}, {"a", EvSit, 3}}},
+		{"离座", []step{{"a", EvSit, 2}, {"b", EvSit, 3}, {"b", EvLeave, 0}}},
+		{"换座后离座", []step{{"a", EvSit, 0}, {"a", EvSit, 3}, {"a", EvLeave, 0}, {"b", EvSit, 0}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := NewGameState("t")
+			for _, s := range tc.steps {
+				var payload any = struct{}{}
+				if s.typ == EvSit {
+					payload = SitPayload{Seat: s.seat}
+				}
+				res, err := Reduce(st, NewFixedSeedSource(), s.uid, s.typ, payload)
+				if err != nil {
+					t.Fatalf("%s %s: %v", s.uid, s.typ, err)
+				}
+				st = res.State
+			}
+			for i, seat := range st.Seats {
+				if seat.Team != TeamOfSeat(i) {
+					t.Errorf("%d号位（%q）的队伍为 %d，期望 %d", i, seat.UID, seat.Team, TeamOfSeat(i))
+				}
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package rules
 
 import (
-	"math/rand"
-	"time"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
 )
 
 const DoubleDeckSize = 108
@@ -38,11 +41,55 @@ func NewDoubleDeck() []Card {
 	return deck
 }
 
-// ShuffleInPlace 最优级别的算法（Fisher–Yates 洗牌）
-func ShuffleInPlace(deck []Card) {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+// Seed 洗牌种子：256 位，整个作为 ChaCha8 的密钥，不会被截短，无法离线穷举
+// 文本形式为 64 位十六进制；复现/调试时也可写十进制整数（见 SeedFromInt）
+type Seed [32]byte
+
+// SeedFromInt 调试用的短种子：整数按小端写入前 8 字节，其余为 0
+func SeedFromInt(n int64) Seed {
+	var s Seed
+	binary.LittleEndian.PutUint64(s[:8], uint64(n))
+	return s
+}
+
+// ParseSeed 解析 64 位十六进制种子，或十进制整数短种子
+func ParseSeed(text string) (Seed, error) {
+	var s Seed
+	if len(text) == 2*len(s) {
+		if _, err := hex.Decode(s[:], []byte(text)); err == nil {
+			return s, nil
+		}
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return s, fmt.Errorf("种子应为 %d 位十六进制或十进制整数: %q", 2*len(s), text)
+	}
+	return SeedFromInt(n), nil
+}
+
+func (s Seed) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func (s Seed) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Seed) UnmarshalText(b []byte) error {
+	v, err := ParseSeed(string(b))
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// ShuffleInPlace 最优级别的算法（Fisher–Yates 洗牌），随机数来自以 seed 为密钥的 ChaCha8
+// 同一 seed 必然得到同一副牌序，便于复现发牌
+func ShuffleInPlace(deck []Card, seed Seed) {
+	r := rand.New(rand.NewChaCha8(seed))
 	for i := len(deck) - 1; i > 0; i-- {
-		j := r.Intn(i + 1)
+		j := r.IntN(i + 1)
 		deck[i], deck[j] = deck[j], deck[i]
 	}
 }
@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func shuffled(seed Seed) []int {
+	deck := NewDoubleDeck()
+	ShuffleInPlace(deck, seed)
+	ids := make([]int, len(deck))
+	for i, c := range deck {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+func TestShuffleUsesWholeSeed(t *testing.T) {
+	base := SeedFromInt(42)
+	if !slices.Equal(shuffled(base), shuffled(base)) {
+		t.Fatal("同一种子两次洗牌结果不同")
+	}
+	// 只差在最后一个字节（旧实现会把种子截到 31 位，这类差别被丢弃）
+	for i := 8; i < len(base); i++ {
+		other := base
+		other[i] ^= 0x80
+		if slices.Equal(shuffled(base), shuffled(other)) {
+			t.Fatalf("种子第%d字节不同，洗牌结果却相同", i)
+		}
+	}
+}
+
+func TestParseSeed(t *testing.T) {
+	full := Seed{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32}
+	cases := []struct {
+		in   string
+		want Seed
+		ok   bool
+	}{
+		{full.String(), full, true},
+		{"42", SeedFromInt(42), true},
+		{"-7", SeedFromInt(-7), true},
+		{"", Seed{}, false},
+		{"0x2a", Seed{}, false},
+		{full.String()[:63] + "g", Seed{}, false},
+	}
+	for _, tc := range cases {
+		got, err := ParseSeed(tc.in)
+		if (err == nil) != tc.ok || (tc.ok && got != tc.want) {
+			t.Errorf("ParseSeed(%q) = %s, %v", tc.in, got, err)
+		}
+	}
+
+	b, err := json.Marshal(struct{ Seed *Seed }{&full})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back struct{ Seed *Seed }
+	if err := json.Unmarshal(b, &back); err != nil || back.Seed == nil || *back.Seed != full {
+		t.Fatalf("JSON 往返失败: %s -> %v, %v", b, back.Seed, err)
+	}
+}
@@ -0,0 +1,311 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ============================
+// 合法出牌生成（提示 / 机器人 / 托管 / 校验器模糊测试的基础）
+// ============================
+
+// Candidate 一个合法出牌候选
+type Candidate struct {
+	Cards      []Card    `json:"cards"`
+	SuitClass  SuitClass `json:"suitClass"`  // 先手：出牌牌域；跟牌：ClassifyPaddingAndComparable 的牌域
+	Blocks     [][]Block `json:"blocks"`     // 先手：DecomposeThrow 结果；跟牌：仅可比时分解
+	Padding    bool      `json:"padding"`    // 跟牌：是否垫牌
+	Comparable bool      `json:"comparable"` // 跟牌：是否参与赢墩比较
+}
+
+// LegalLeads 先手的规范候选：每个牌域内的所有单张、对子、各长度拖拉机（同花同点去重）。
+// 任意同牌域子集都是合法先手（多块即甩牌，由 canonicalizeLead 裁剪），这里不枚举多块甩牌。
+func LegalLeads(hand []Card, t Trump) ([]Candidate, error) {
+	out := make([]Candidate, 0, len(hand))
+	seen := make(map[string]struct{})
+	add := func(sc SuitClass, cards []Card) error {
+		key := faceKey(cards)
+		if _, ok := seen[key]; ok {
+			return nil
+		}
+		seen[key] = struct{}{}
+		blocks, err := DecomposeThrow(cards, t, sc)
+		if err != nil {
+			return err
+		}
+		out = append(out, Candidate{
+			Cards:      append([]Card(nil), cards...),
+			SuitClass:  sc,
+			Blocks:     blocks,
+			Comparable: true,
+		})
+		return nil
+	}
+	for _, sc := range []SuitClass{SCTrump, SCH, SCS, SCD, SCC} {
+		singles, err := FindBlocksInHand(hand, t, sc, BlockSingle, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(singles) == 0 {
+			continue
+		}
+		pairs, err := FindBlocksInHand(hand, t, sc, BlockPair, 0)
+		if err != nil {
+			return nil, err
+		}
+		var tractors []Block
+		for l := 2; l <= len(pairs); l++ {
+			ts, err := buildTractors(filterBySuitClass(hand, sc), t, sc, l)
+			if err != nil {
+				return nil, err
+			}
+			if len(ts) == 0 {
+				break
+			}
+			tractors = append(tractors, ts...)
+		}
+		for _, group := range [][]Block{singles, pairs, tractors} {
+			for _, b := range group {
+				if err := add(sc, b.Cards); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// maxFollowTries 跟牌生成最多尝试（并交给 ValidateHideCheck 校验）的组合数。
+// 生成在房间协程里为机器人、托管同步调用，必须有界；正常手牌第一个组合就是合法跟牌。
+const maxFollowTries = 512
+
+// LegalFollows 枚举跟牌的合法候选（同花同点视为同一张，结果去重）。
+// 每个候选都通过 ValidateHideCheck，并按 ClassifyPaddingAndComparable 标注垫牌/可比。
+// 枚举顺序为“从小到大”：第一个候选即最小的合法跟牌。limit<=0 表示不限数量（仍受 maxFollowTries 限制）。
+//   - 本牌域不够：本牌域全出，其余从别的牌里按垫牌代价由小到大补齐，任意补法都合法
+//   - 本牌域充足：按先手牌型构造（拖拉机、对子优先，再补单张，见 followBuilder），不在 C(n,k) 组合上逐个试
+func LegalFollows(hand []Card, leadMove [][]Block, t Trump, limit int) ([]Candidate, error) {
+	if len(leadMove) == 0 || len(leadMove[0]) == 0 {
+		return nil, fmt.Errorf("合法跟牌生成出错，leadMove出现空组")
+	}
+	need := countBlocksCards(leadMove)
+	if len(hand) < need {
+		return nil, fmt.Errorf("手牌不足%d张", need)
+	}
+	leadSC := leadMove[0][0].SuitClass
+	handLead := filterBySuitClass(hand, leadSC)
+
+	out := make([]Candidate, 0)
+	seen := make(map[string]struct{})
+	tries := 0
+	var firstErr error
+	// try 校验一个组合并加入结果；返回 false 表示停止生成
+	try := func(played []Card) bool {
+		tries++
+		key := faceKey(played)
+		if _, ok := seen[key]; ok {
+			return tries < maxFollowTries
+		}
+		seen[key] = struct{}{}
+		if ValidateHideCheck(hand, played, leadMove, t) != nil {
+			return tries < maxFollowTries
+		}
+		padding, comparable, sc := ClassifyPaddingAndComparable(played, leadSC)
+		cand := Candidate{
+			Cards:      played,
+			SuitClass:  sc,
+			Padding:    padding,
+			Comparable: comparable,
+		}
+		if comparable {
+			blocks, err := DecomposeThrow(played, t, sc)
+			if err != nil {
+				firstErr = err
+				return false
+			}
+			cand.Blocks = blocks
+		}
+		out = append(out, cand)
+		return (limit <= 0 || len(out) < limit) && tries < maxFollowTries
+	}
+
+	if len(handLead) <= need {
+		pool := make([]Card, 0, len(hand)-len(handLead))
+		for _, c := range hand {
+			if c.SuitClass != leadSC {
+				pool = append(pool, c)
+			}
+		}
+		sort.SliceStable(pool, func(i, j int) bool { return paddingCost(pool[i], t) < paddingCost(pool[j], t) })
+		enumFaces(groupFaces(pool), need-len(handLead), func(picked []Card) bool {
+			return try(append(append([]Card(nil), handLead...), picked...))
+		})
+		return out, firstErr
+	}
+
+	reqs, err := flattenLeadReqs(leadMove)
+	if err != nil {
+		return nil, err
+	}
+	b := &followBuilder{t: t, sc: leadSC, reqs: reqs, yield: try}
+	b.req(0, handLead, nil)
+	if b.err != nil {
+		return nil, b.err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if len(out) == 0 {
+		// 兜底：构造与校验器的贪心选块不一致时，退回按牌面由小到大逐个组合校验（同样受 maxFollowTries 限制）
+		pool := append([]Card(nil), handLead...)
+		sort.SliceStable(pool, func(i, j int) bool { return rankValue(pool[i], t) < rankValue(pool[j], t) })
+		tries = 0
+		enumFaces(groupFaces(pool), need, try)
+	}
+	return out, firstErr
+}
+
+// followBuilder 本牌域充足时按 ValidateHideCheck 的逐块匹配顺序构造纯域跟牌：
+// 先手的每个牌型依次尝试降级方案（buildPlans），方案中的子牌型若剩余手牌里有，就必须从中选一块（由小到大逐个尝试），
+// 没有则换下一个方案。这样构造出的出牌不会藏牌，组合数只随先手块数增长。
+type followBuilder struct {
+	t     Trump
+	sc    SuitClass
+	reqs  []reqBlock
+	yield func([]Card) bool
+	err   error
+}
+
+// req 为第 i 个先手牌型选方案；返回 false 表示停止生成
+func (b *followBuilder) req(i int, hand, played []Card) bool {
+	if i == len(b.reqs) {
+		return b.yield(played)
+	}
+	for _, plan := range buildPlans(b.reqs[i]) {
+		matched := false
+		if !b.sub(i, plan, 0, hand, played, &matched) {
+			return false
+		}
+		// 与校验器一致：第一个能在手牌中凑出的方案即为该牌型的跟法，不再降级
+		if matched {
+			return true
+		}
+	}
+	return true
+}
+
+// sub 依次满足方案中的子牌型；连续的同型子牌型按牌力不降的顺序选块，避免同一组合的不同排列
+func (b *followBuilder) sub(i int, plan []subReq, floor int, hand, played []Card, matched *bool) bool {
+	if len(plan) == 0 {
+		*matched = true
+		return b.req(i+1, hand, played)
+	}
+	blocks, err := FindBlocksInHand(hand, b.t, b.sc, plan[0].bt, plan[0].tractorLen)
+	if err != nil {
+		b.err = err
+		return false
+	}
+	sameNext := len(plan) > 1 && plan[1].bt == plan[0].bt && plan[1].tractorLen == plan[0].tractorLen
+	seen := make(map[string]struct{}, len(blocks))
+	// FindBlocksInHand 按牌力降序，从最小的一块开始
+	for k := len(blocks) - 1; k >= 0; k-- {
+		blk := blocks[k]
+		if blk.RankValue < floor {
+			continue
+		}
+		key := faceKey(blk.Cards)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		next := 0
+		if sameNext {
+			next = blk.RankValue
+		}
+		rest := deleteCards(hand, blk.Cards)
+		picked := append(append(make([]Card, 0, len(played)+len(blk.Cards)), played...), blk.Cards...)
+		if !b.sub(i, plan[1:], next, rest, picked, matched) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheapestCards 取代价最小的 n 张牌（扣底、垫牌、托管出牌用）
+func CheapestCards(cards []Card, t Trump, n int) []Card {
+	out := append([]Card(nil), cards...)
+	sort.SliceStable(out, func(i, j int) bool { return paddingCost(out[i], t) < paddingCost(out[j], t) })
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// paddingCost 垫牌代价：副牌优先、无分牌优先、牌力小优先
+func paddingCost(c Card, t Trump) int {
+	cost := rankValue(c, t)
+	if TrickPoints([]Card{c}) > 0 {
+		cost += 500
+	}
+	return cost
+}
+
+// groupFaces 把已排序的牌按“同花同点”分组（两副牌中最多 2 张），保持原顺序
+func groupFaces(cards []Card) [][]Card {
+	out := make([][]Card, 0, len(cards))
+	idx := make(map[string]int, len(cards))
+	for _, c := range cards {
+		key := string(c.Suit) + string(c.Rank)
+		if i, ok := idx[key]; ok {
+			out[i] = append(out[i], c)
+			continue
+		}
+		idx[key] = len(out)
+		out = append(out, []Card{c})
+	}
+	return out
+}
+
+// enumFaces 在 faces 中选出 k 张（每个 face 选 0..len 张），按字典序“先取小牌”回调；yield 返回 false 时停止
+func enumFaces(faces [][]Card, k int, yield func([]Card) bool) {
+	// suffix[i] = faces[i:] 的总张数，用于剪枝
+	suffix := make([]int, len(faces)+1)
+	for i := len(faces) - 1; i >= 0; i-- {
+		suffix[i] = suffix[i+1] + len(faces[i])
+	}
+	picked := make([]Card, 0, k)
+	var dfs func(i, left int) bool
+	dfs = func(i, left int) bool {
+		if left == 0 {
+			return yield(append([]Card(nil), picked...))
+		}
+		if i >= len(faces) || suffix[i] < left {
+			return true
+		}
+		n := len(faces[i])
+		if n > left {
+			n = left
+		}
+		for take := n; take >= 0; take-- {
+			picked = append(picked, faces[i][:take]...)
+			cont := dfs(i+1, left-take)
+			picked = picked[:len(picked)-take]
+			if !cont {
+				return false
+			}
+		}
+		return true
+	}
+	dfs(0, k)
+}
+
+// faceKey 同花同点去重用的规范键
+func faceKey(cards []Card) string {
+	keys := make([]string, 0, len(cards))
+	for _, c := range cards {
+		keys = append(keys, string(c.Suit)+string(c.Rank))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,217 @@
+package rules
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var faceSuits = map[byte]Suit{'S': Spade, 'H': Heart, 'C': Club, 'D': Diamond}
+
+// cardsOf 按牌面简写（S10 HA LJ BJ）从一副新牌中取牌，同名牌依次取两副中的不同 ID，并按 t 标注牌域
+func cardsOf(t testing.TB, tr Trump, faces string) []Card {
+	t.Helper()
+	deck := NewDoubleDeck()
+	used := make(map[int]bool)
+	var out []Card
+	for _, f := range strings.Fields(faces) {
+		var suit Suit
+		var rank Rank
+		switch f {
+		case "LJ":
+			suit, rank = SmallJoker, RSJ
+		case "BJ":
+			suit, rank = BigJoker, RBJ
+		default:
+			suit, rank = faceSuits[f[0]], Rank(f[1:])
+		}
+		found := false
+		for _, c := range deck {
+			if c.Suit == suit && c.Rank == rank && !used[c.ID] {
+				used[c.ID] = true
+				c.SuitClass = ComputeSuitClass(c, tr)
+				out = append(out, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("没有可用的牌 %s", f)
+		}
+	}
+	return out
+}
+
+func leadOf(t testing.TB, tr Trump, faces string) [][]Block {
+	t.Helper()
+	cards := cardsOf(t, tr, faces)
+	blocks, err := DecomposeThrow(cards, tr, cards[0].SuitClass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blocks
+}
+
+func facesOf(cards []Card) string {
+	out := make([]string, len(cards))
+	for i, c := range cards {
+		switch c.Suit {
+		case SmallJoker:
+			out[i] = "LJ"
+		case BigJoker:
+			out[i] = "BJ"
+		default:
+			for b, s := range faceSuits {
+				if s == c.Suit {
+					out[i] = string(b) + string(c.Rank)
+				}
+			}
+		}
+	}
+	slices.Sort(out)
+	return strings.Join(out, " ")
+}
+
+var heartTrump = Trump{HasTrumpSuit: true, Suit: Heart, LevelRank: R2}
+
+// 先手 4 对 + 2 单的主牌甩牌，跟牌者 20 张主牌中的四对都是大牌：逐个组合校验要试几十万次
+const (
+	slowLead = "HA HA HQ HQ H10 H10 H8 H8 H6 H4"
+	slowHand = "BJ BJ LJ LJ H2 H2 S2 S2 C2 D2 HK HQ HJ H10 H9 H8 H7 H6 H5 H4"
+)
+
+func TestLegalFollowsSmallest(t *testing.T) {
+	cases := []struct {
+		name string
+		hand string
+		lead string
+		want string
+	}{
+		{"单张跟最小", "S3 S9 SK H5", "SA", "S3"},
+		{"有对必跟对", "S3 S9 S9 SK H5", "SA SA", "S9 S9"},
+		{"无对跟两张最小", "S3 S9 SK H5", "SA SA", "S3 S9"},
+		{"有拖拉机必跟拖拉机", "S3 S3 S5 S5 S6 S6 S9", "SA SA SK SK", "S5 S5 S6 S6"},
+		{"无拖拉机跟两对", "S3 S3 S9 S9 SJ", "SA SA SK SK", "S3 S3 S9 S9"},
+		{"本门不够全出再垫最小副牌", "S3 C4 C10 H5", "SA SA", "C4 S3"},
+		{"主牌甩牌必须跟出对子", slowHand, slowLead, "BJ BJ LJ LJ H2 H2 S2 S2 H4 H5"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			hand := cardsOf(t, heartTrump, tc.hand)
+			lead := leadOf(t, heartTrump, tc.lead)
+			cands, err := LegalFollows(hand, lead, heartTrump, 1)
+			if err != nil || len(cands) != 1 {
+				t.Fatalf("LegalFollows = %d 个候选, %v", len(cands), err)
+			}
+			if got := facesOf(cands[0].Cards); got != facesOf(cardsOf(t, heartTrump, tc.want)) {
+				t.Fatalf("最小跟牌 %s，期望 %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// randomTrump 随机定主：有主花色或硬主，级牌随机
+func randomTrump(r *rand.Rand) Trump {
+	ranks := []Rank{R2, R3, R5, R10, RK, RA}
+	tr := Trump{LevelRank: ranks[r.IntN(len(ranks))]}
+	if r.IntN(4) > 0 {
+		tr.HasTrumpSuit = true
+		tr.Suit = []Suit{Spade, Heart, Club, Diamond}[r.IntN(4)]
+	}
+	return tr
+}
+
+// randomLead 从先手手牌某个牌域中随机取 1~10 张（多块即甩牌）
+func randomLead(r *rand.Rand, hand []Card, tr Trump) ([]Card, SuitClass) {
+	sc := hand[r.IntN(len(hand))].SuitClass
+	pool := filterBySuitClass(hand, sc)
+	r.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	n := 1 + r.IntN(min(len(pool), 10))
+	return pool[:n], sc
+}
+
+// 模糊测试：随机发牌、随机定主、随机先手（含甩牌），生成器给出的每个先手都能被 DecomposeThrow 分解为同一牌域，
+// 每个跟牌都通过 ValidateHideCheck，且出牌都来自手牌、张数正确
+func TestGeneratedMovesPassValidators(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for round := 0; round < 300; round++ {
+		deck := NewDoubleDeck()
+		ShuffleInPlace(deck, SeedFromInt(int64(round)))
+		tr := randomTrump(r)
+		for i := range deck {
+			deck[i].SuitClass = ComputeSuitClass(deck[i], tr)
+		}
+		hands, _ := Deal(deck)
+		// 打到后期手牌变少，一门牌更容易不够
+		size := 1 + r.IntN(25)
+		for s := range hands {
+			hands[s] = hands[s][:size]
+		}
+
+		leads, err := LegalLeads(hands[0], tr)
+		if err != nil || len(leads) == 0 {
+			t.Fatalf("第%d局 LegalLeads = %d, %v", round, len(leads), err)
+		}
+		for _, c := range leads {
+			sc, ok := ComputeSuitClassAllSame(c.Cards)
+			if !ok || sc != c.SuitClass || !containsAll(hands[0], c.Cards) {
+				t.Fatalf("第%d局先手 %s 不是手中同一牌域的牌", round, facesOf(c.Cards))
+			}
+			if _, err := DecomposeThrow(c.Cards, tr, sc); err != nil {
+				t.Fatalf("第%d局先手 %s 无法分解: %v", round, facesOf(c.Cards), err)
+			}
+		}
+
+		for try := 0; try < 4; try++ {
+			leadCards, sc := randomLead(r, hands[0], tr)
+			if try == 0 {
+				c := leads[r.IntN(len(leads))]
+				leadCards, sc = c.Cards, c.SuitClass
+			}
+			lead, err := DecomposeThrow(leadCards, tr, sc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for s := 1; s < 4; s++ {
+				cands, err := LegalFollows(hands[s], lead, tr, 5)
+				if err != nil || len(cands) == 0 {
+					t.Fatalf("第%d局 %d号位跟 %s：%d 个候选, %v\n手牌 %s", round, s, facesOf(leadCards), len(cands), err, facesOf(hands[s]))
+				}
+				for _, c := range cands {
+					if len(c.Cards) != len(leadCards) || !containsAll(hands[s], c.Cards) {
+						t.Fatalf("第%d局 %d号位跟牌 %s 张数不对或不在手牌中", round, s, facesOf(c.Cards))
+					}
+					if err := ValidateHideCheck(hands[s], c.Cards, lead, tr); err != nil {
+						t.Fatalf("第%d局 %d号位跟 %s 的候选 %s 未通过藏牌校验: %v\n手牌 %s",
+							round, s, facesOf(leadCards), facesOf(c.Cards), err, facesOf(hands[s]))
+					}
+				}
+			}
+		}
+	}
+}
+
+func containsAll(hand, cards []Card) bool {
+	ids := make(map[int]bool, len(hand))
+	for _, c := range hand {
+		ids[c.ID] = true
+	}
+	for _, c := range cards {
+		if !ids[c.ID] {
+			return false
+		}
+		delete(ids, c.ID)
+	}
+	return true
+}
+
+func BenchmarkLegalFollowsTrumpThrow(b *testing.B) {
+	hand := cardsOf(b, heartTrump, slowHand)
+	lead := leadOf(b, heartTrump, slowLead)
+	for b.Loop() {
+		if _, err := LegalFollows(hand, lead, heartTrump, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -15,28 +15,45 @@ func TrickPoints(cards []Card) int {
 	return sum
 }
 
-func DigMultiplierByWinnerMove(winType BlockType) int {
-	switch winType {
+// DigRule 末墩抠底倍数：按打家赢末墩时首个牌组的牌型取倍数
+// TractorPow2=true 时拖拉机倍数为 2^连对数（忽略 Tractor）
+type DigRule struct {
+	Single      int  `json:"single"`
+	Pair        int  `json:"pair"`
+	Tractor     int  `json:"tractor"`
+	TractorPow2 bool `json:"tractorPow2,omitempty"`
+}
+
+// StandardDig 单张 ×1、对子 ×2、拖拉机 ×4
+var StandardDig = DigRule{Single: 1, Pair: 2, Tractor: 4}
+
+func (d DigRule) Multiplier(b Block) int {
+	switch b.Type {
 	case BlockSingle:
-		return 1
+		return d.Single
 	case BlockPair:
-		return 2
+		return d.Pair
 	case BlockTractor:
-		return 4
+		if d.TractorPow2 {
+			return 1 << b.TractorLen
+		}
+		return d.Tractor
 	default:
 		return 1
 	}
 }
 
-func AddRank(r Rank, delta int) Rank {
+// AddRank 级牌升级，返回升级后的级牌以及是否已打过 A（整局结束）
+// 已在 A 的队伍再升级即视为打过 A，级牌停留在 A
+func AddRank(r Rank, delta int) (Rank, bool) {
 	if delta <= 0 {
-		return r
+		return r, false
 	}
 	// 级牌升级序列（不含大小王）
 	seq := []Rank{R2, R3, R4, R5, R6, R7, R8, R9, R10, RJ, RQ, RK, RA}
 	// Pending：保持不变（如果你希望 Pending + delta 从 R2 开始，可在这里改）
 	if r == RPending || r == RBJ || r == RSJ {
-		return R2
+		return R2, false
 	}
 	// 找到当前 rank 在序列中的位置
 	idx := -1
@@ -47,11 +64,14 @@ func AddRank(r Rank, delta int) Rank {
 		}
 	}
 	if idx == -1 {
-		return R2
+		return R2, false
+	}
+	if r == RA {
+		return RA, true
 	}
 	nidx := idx + delta
 	if nidx >= len(seq) {
-		nidx = 0 // 无尽模式
+		nidx = len(seq) - 1 // 封顶 A，需要在 A 上再赢一次才算打过
 	}
-	return seq[nidx]
+	return seq[nidx], false
 }
@@ -0,0 +1,60 @@
+package game
+
+import (
+	crand "crypto/rand"
+	"sync"
+
+	"upgrade-lan/internal/game/rules"
+)
+
+// SeedSource 发牌种子来源，由 room 持有并注入 Reduce
+// - 线上对局使用 CryptoSeedSource，保证公平
+// - 复现/调试使用 FixedSeedSource，按给定序列发牌
+type SeedSource interface {
+	NextSeed() rules.Seed
+}
+
+// CryptoSeedSource 基于 crypto/rand 的种子来源，每次取满 256 位
+type CryptoSeedSource struct{}
+
+func (CryptoSeedSource) NextSeed() rules.Seed {
+	var s rules.Seed
+	if _, err := crand.Read(s[:]); err != nil {
+		panic("crypto/rand 不可用: " + err.Error())
+	}
+	return s
+}
+
+// FixedSeedSource 按固定序列依次返回种子，用完后从头循环
+type FixedSeedSource struct {
+	mu    sync.Mutex
+	seeds []rules.Seed
+	next  int
+}
+
+func NewFixedSeedSource(seeds ...rules.Seed) *FixedSeedSource {
+	return &FixedSeedSource{seeds: append([]rules.Seed(nil), seeds...)}
+}
+
+func (s *FixedSeedSource) NextSeed() rules.Seed {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.seeds) == 0 {
+		return rules.Seed{}
+	}
+	seed := s.seeds[s.next%len(s.seeds)]
+	s.next++
+	return seed
+}
+
+// SeedRecorder 包装一个 SeedSource，记录本次 Reduce 实际取用的种子（写入 replay log）
+type SeedRecorder struct {
+	Src  SeedSource
+	Used *rules.Seed
+}
+
+func (r *SeedRecorder) NextSeed() rules.Seed {
+	seed := r.Src.NextSeed()
+	r.Used = &seed
+	return seed
+}
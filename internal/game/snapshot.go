@@ -1,12 +1,25 @@
 package game
 
-import "upgrade-lan/internal/game/rules"
+import (
+	"upgrade-lan/internal/game/rules"
+	"upgrade-lan/internal/jsonpatch"
+)
 
 type Snapshot struct {
 	Type  string    `json:"type"` // "snapshot"
 	State ViewState `json:"state"`
 }
 
+// DeltaMsg 差量快照（客户端通过 room.sync 开启）
+// - BaseVersion：差量基于的 ViewState.Version，客户端当前版本不一致时应发送 room.sync 重新同步
+// - Ops：作用于上一次下发的 ViewState 的 JSON-Patch
+type DeltaMsg struct {
+	Type        string         `json:"type"` // "delta"
+	BaseVersion int64          `json:"baseVersion"`
+	Version     int64          `json:"version"`
+	Ops         []jsonpatch.Op `json:"ops"`
+}
+
 type ViewState struct {
 	RoomID  string      `json:"roomId"`
 	Phase   Phase       `json:"phase"`
@@ -24,6 +37,8 @@ type ViewState struct {
 	FightPassCount   int      `json:"fightPassCount"`
 	BottomOwnerSeat  int      `json:"bottomOwnerSeat"`
 
+	Declarations []Declaration `json:"declarations"` // 本小局定主/改主/攻主记录（亮出的牌本就公开）
+
 	TrickIndex int        `json:"trickIndex"` // 本小局第几墩，从0开始
 	Trump      TrumpState `json:"trump"`
 	Trick      TrickState `json:"trick"`      // 全部可见
@@ -39,11 +54,35 @@ type ViewState struct {
 	BottomAward    int          `json:"bottomAward"`
 
 	// 小局结算展示（PhaseRoundSettle 用）
-	RoundPointsFinal int    `json:"roundPointsFinal"`
-	RoundResultLabel string `json:"roundResultLabel"`
-	CallerDelta      int    `json:"callerDelta"`
-	DefenderDelta    int    `json:"defenderDelta"`
-	NextStarterSeat  int    `json:"nextStarterSeat"` // 关键：谁可以点“开始下一局”
+	RoundPointsFinal int         `json:"roundPointsFinal"`
+	RoundResultLabel string      `json:"roundResultLabel"`
+	CallerDelta      int         `json:"callerDelta"`
+	DefenderDelta    int         `json:"defenderDelta"`
+	NextStarterSeat  int         `json:"nextStarterSeat"`    // 关键：谁可以点“开始下一局”
+	DealSeed         *rules.Seed `json:"dealSeed,omitempty"` // 本小局发牌种子，小局结束后公开，用于复现
+
+	// 整局结束展示（PhaseGameOver 用）
+	Match *MatchResult `json:"match,omitempty"`
+
+	// 规则方案（只读，房主在大厅通过 room.settings 修改）
+	Config RoomConfig `json:"config"`
+
+	// 房主（由 room 填写）
+	Host string `json:"host"`
+
+	// 悔棋：DisableUndo 为房主设置；UndoVote 为进行中的表决（由 room 填写）
+	DisableUndo bool          `json:"disableUndo"`
+	UndoVote    *UndoVoteView `json:"undoVote,omitempty"`
+
+	// 观战（由 room 填写）
+	Spectators []string `json:"spectators"`           // 观战者 uid 列表
+	Spectating bool     `json:"spectating,omitempty"` // 本连接是否为观战者（观战视图可能有延迟）
+	FollowSeat int      `json:"followSeat"`           // 观战者跟随的座位，-1 表示不跟随；跟随时 MyHand 为该座位（延迟后的）手牌
+
+	// 操作时限（由 room 填写）：Timers 为本房间生效的各阶段时限（房主未设置时为服务端默认值）；
+	// Deadline 为当前等待窗口的基础截止时间，unix 毫秒，0 表示不计时
+	Timers   TurnTimers `json:"timers"`
+	Deadline int64      `json:"deadline,omitempty"`
 
 	MySeat   int            `json:"mySeat"`
 	MyBottom []rules.Card   `json:"myBottom"` // 仅在 PhaseBottom 本人可见
@@ -56,20 +95,66 @@ type SeatView struct {
 	Online    bool   `json:"online"`
 	Team      int    `json:"team"`
 	HandCount int    `json:"handCount"`
+
+	TimeBankMs int64 `json:"timeBankMs,omitempty"` // 棋钟剩余（由 room 填写），可在 Deadline 之后继续透支
+	Locked     bool  `json:"locked,omitempty"`     // 空座位被房主锁定（由 room 填写）
+}
+
+// UndoVoteView 悔棋表决进度：撤回 TargetUID 的 Action，Accepted 为已同意的 uid（含发起者）
+type UndoVoteView struct {
+	Proposer  string          `json:"proposer"`
+	TargetUID string          `json:"targetUid"`
+	Action    ClientEventType `json:"action"`
+	Accepted  []string        `json:"accepted"`
 }
 
 type TeamView struct {
 	LevelRank rules.Rank `json:"levelRank"`
 }
 
+// ErrorMsg 下发给客户端的错误
+// - Event：出错的客户端事件类型（握手阶段的错误为空）
+// - ReqID：出错命令带的请求 ID（未带则为空），与 ack 对应
 type ErrorMsg struct {
-	Type    string `json:"type"`
-	Message string `json:"message"`
+	Type     string      `json:"type"` // "error"
+	Code     string      `json:"code"`
+	Category ErrCategory `json:"category"`
+	Message  string      `json:"message"`
+	Info     string      `json:"info,omitempty"`
+	Event    string      `json:"event,omitempty"`
+	ReqID    string      `json:"reqId,omitempty"`
+}
+
+// AckMsg 每个客户端命令的处理回执
+// - ReqID：客户端带上的请求 ID（未带则为空）
+// - OK=false 时 Code 为 AppError.Code
+// - Version：处理后的 GameState.Version
+type AckMsg struct {
+	Type    string `json:"type"` // "ack"
+	ReqID   string `json:"reqId"`
+	OK      bool   `json:"ok"`
+	Code    string `json:"code,omitempty"`
+	Version int64  `json:"version"`
 }
 
+// NoticeMsg 结构化通知：Code/Params 供前端做样式或自行翻译，Message 为按连接语言渲染好的文案
 type NoticeMsg struct {
-	Type    string `json:"type"`
-	Message string `json:"message"`
+	Type    string       `json:"type"` // "notice"
+	Code    NoticeCode   `json:"code"`
+	Params  NoticeParams `json:"params,omitempty"`
+	Message string       `json:"message"`
+}
+
+// EventMsg 领域事件（见 domain.go），Version 为产生该事件的 state 版本；同一版本可能有多条，按顺序下发
+type EventMsg struct {
+	Type    string      `json:"type"` // "event"
+	Version int64       `json:"version"`
+	Kind    EventKind   `json:"kind"`
+	Event   DomainEvent `json:"event"`
+}
+
+func NewEventMsg(version int64, e DomainEvent) EventMsg {
+	return EventMsg{Type: "event", Version: version, Kind: e.EventKind(), Event: e}
 }
 
 // MakeView 后端永远保存完整 state，但下发永远走 view
@@ -93,7 +178,7 @@ func MakeView(st GameState, uid string) ViewState {
 			Team:      st.Seats[i].Team,
 			HandCount: st.Seats[i].HandCount,
 		}
-		if st.Seats[i].UID == uid {
+		if uid != "" && st.Seats[i].UID == uid {
 			mySeat = i
 			myHand = append([][]rules.Card(nil), groupBySuitClass(st.Seats[i].Hand)...)
 		}
@@ -110,6 +195,13 @@ func MakeView(st GameState, uid string) ViewState {
 		}
 	}
 
+	// 发牌种子：小局结束前公开会泄露所有人手牌
+	var dealSeed *rules.Seed
+	if st.Phase == PhaseRoundSettle || st.Phase == PhaseGameOver {
+		seed := st.DealSeed
+		dealSeed = &seed
+	}
+
 	// 末墩公开底牌：对所有人可见（你现在就是这个语义）
 	bottomReveal := []rules.Card(nil)
 	if st.BottomRevealed && len(st.BottomReveal) > 0 {
@@ -134,6 +226,7 @@ func MakeView(st GameState, uid string) ViewState {
 		FightPassedSeats: fightPassed,
 		FightPassCount:   st.FightPassCount,
 		Trump:            st.Trump,
+		Declarations:     st.Declarations,
 
 		BottomOwnerSeat: st.BottomOwnerSeat,
 
@@ -141,6 +234,8 @@ func MakeView(st GameState, uid string) ViewState {
 		MyBottom: myBottom,
 		MyHand:   myHand,
 
+		FollowSeat: -1,
+
 		Trick:      st.Trick,
 		Points:     st.Points,
 		HideRecord: st.HideRecord,
@@ -160,7 +255,28 @@ func MakeView(st GameState, uid string) ViewState {
 		CallerDelta:      st.CallerDelta,
 		DefenderDelta:    st.DefenderDelta,
 		NextStarterSeat:  st.NextStarterSeat, // 关键
+		DealSeed:         dealSeed,
+
+		// 整局结束
+		Match: st.Match,
+
+		Config:      st.Config,
+		DisableUndo: st.DisableUndo,
+	}
+}
+
+// MakeSpectatorView 观战视图：只含公开信息；followSeat>=0 时附带该座位的手牌（调用方负责传入延迟后的 state）
+func MakeSpectatorView(st GameState, followSeat int) ViewState {
+	if followSeat < 0 || followSeat > 3 || st.Seats[followSeat].UID == "" {
+		v := MakeView(st, "")
+		v.Spectating = true
+		return v
 	}
+	v := MakeView(st, st.Seats[followSeat].UID)
+	v.MySeat = -1
+	v.Spectating = true
+	v.FollowSeat = followSeat
+	return v
 }
 
 func maskToBool4(m uint8) (out [4]bool) {
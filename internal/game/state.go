@@ -12,6 +12,7 @@ const (
 	PhaseTrumpFight  Phase = "trump_fight"
 	PhasePlayTrick   Phase = "play_trick"
 	PhaseRoundSettle Phase = "round_settle"
+	PhaseGameOver    Phase = "game_over"
 )
 
 type SeatState struct {
@@ -34,6 +35,24 @@ type TrumpState struct {
 	CallerSeat int  `json:"callerSeat"` // -1 表示无人定主（硬主）
 }
 
+// DeclKind 亮牌声明类型
+type DeclKind string
+
+const (
+	DeclCall   DeclKind = "call"   // 定主
+	DeclChange DeclKind = "change" // 改主
+	DeclAttack DeclKind = "attack" // 攻主
+)
+
+// Declaration 本小局的一次定主/改主/攻主（亮出的牌对所有人公开）
+type Declaration struct {
+	Seat    int          `json:"seat"`
+	Kind    DeclKind     `json:"kind"`
+	CardIDs []int        `json:"cardIds"`
+	Cards   []rules.Card `json:"cards"`
+	Trump   TrumpState   `json:"trump"` // 声明成功后的主
+}
+
 type CallMode string
 
 const (
@@ -80,6 +99,13 @@ type RoundOutcome struct {
 	NextStarterSeat int
 }
 
+// MatchResult 整局结果（某队打过 A 后写入）
+type MatchResult struct {
+	WinnerTeam  int           `json:"winnerTeam"`  // 获胜队伍 0 or 1
+	Rounds      int           `json:"rounds"`      // 本整局共打了多少小局
+	FinalLevels [2]rules.Rank `json:"finalLevels"` // 两队最终级牌
+}
+
 type SuitRecord struct {
 	A   int `json:"a"`
 	K   int `json:"k"`
@@ -105,9 +131,10 @@ type GameState struct {
 	Teams [2]TeamState `json:"teams"`
 
 	// ---- 小局起始/定主流转信息 ----
-	RoundIndex   int      `json:"roundIndex"` // 第几小局，从0开始
-	CallMode     CallMode `json:"callMode"`   // race / ordered
-	CallPassMask uint8    `json:"-"`          // bit0..bit3 表示seat是否已pass（内部），用于第一小局判定是否无主
+	RoundIndex   int        `json:"roundIndex"` // 第几小局，从0开始
+	CallMode     CallMode   `json:"callMode"`   // race / ordered
+	CallPassMask uint8      `json:"-"`          // bit0..bit3 表示seat是否已pass（内部），用于第一小局判定是否无主
+	DealSeed     rules.Seed `json:"-"`          // 本小局发牌种子（内部），小局结束后才公开
 
 	NextStarterSeat int `json:"-"`             // 跨小局保留：下一小局谁先定主/先手（结算时写）
 	CallerSeat      int `json:"callerSeat"`    // 本小局谁定主
@@ -119,6 +146,9 @@ type GameState struct {
 
 	Trump TrumpState `json:"trump"`
 
+	// 本小局定主/改主/攻主记录：同一张王牌或级牌只能参与一次
+	Declarations []Declaration `json:"declarations"`
+
 	// ---- 底牌 ----
 	BottomCount     int          `json:"bottomCount"`
 	Bottom          []rules.Card `json:"-"`
@@ -131,11 +161,18 @@ type GameState struct {
 	HideRecord bool       `json:"hideRecord"`
 	Record     Record     `json:"record"` // 记牌功能
 
+	DisableUndo bool `json:"disableUndo"` // 房主关闭悔棋（正式比赛）
+
+	// ---- 规则方案（大厅阶段由房主选择）----
+	Config RoomConfig `json:"config"`
+	// ---- 操作时限（大厅阶段由房主设置），nil 表示使用服务端默认值 ----
+	Timers *TurnTimers `json:"timers,omitempty"`
+
 	// ---- 末墩抠底 ----
 	BottomRevealed bool         `json:"bottomRevealed"`         // 是否已经抠/公开底牌（用于断线重连）
 	BottomReveal   []rules.Card `json:"bottomReveal,omitempty"` // 公开给前端展示（可选）
 	BottomPoints   int          `json:"bottomPoints"`           // 底牌分（不含倍率）
-	BottomMul      int          `json:"bottomMul"`              // 倍率（2/4/1）
+	BottomMul      int          `json:"bottomMul"`              // 倍率（按 Config.Dig）
 	BottomAward    int          `json:"bottomAward"`            // 实际加到 st.Points 的分（含倍率，且只在打家得分时生效）
 
 	// ---- 小局结算展示 ----
@@ -143,4 +180,61 @@ type GameState struct {
 	RoundResultLabel string `json:"roundResultLabel"` // 满分/大胜/过大关/换坐/过小关/不过小关/光头
 	CallerDelta      int    `json:"callerDelta"`      // 坐家升级
 	DefenderDelta    int    `json:"defenderDelta"`    // 打家升级
+
+	// ---- 整局结束 ----
+	Match *MatchResult `json:"match,omitempty"` // 仅 PhaseGameOver 有效
+}
+
+// Clone 深拷贝（手牌、底牌等切片不与原 state 共享底层数组）
+// Reduce 会原地排序/改写手牌的 SuitClass，需要保存历史 state 时必须 Clone
+func (st GameState) Clone() GameState {
+	cp := st
+	for i := 0; i < 4; i++ {
+		cp.Seats[i].Hand = append([]rules.Card(nil), st.Seats[i].Hand...)
+	}
+	cp.Bottom = append([]rules.Card(nil), st.Bottom...)
+	cp.BottomReveal = append([]rules.Card(nil), st.BottomReveal...)
+	if st.Match != nil {
+		m := *st.Match
+		cp.Match = &m
+	}
+	cp.Config = st.Config.clone()
+	if st.Timers != nil {
+		t := *st.Timers
+		cp.Timers = &t
+	}
+	cp.Declarations = cloneDeclarations(st.Declarations)
+	return cp
+}
+
+// emptySeat 空座位：队伍由座位号决定，与谁坐过无关
+func emptySeat(i int) SeatState {
+	return SeatState{Team: TeamOfSeat(i)}
+}
+
+// NewGameState 新房间的初始状态（room 创建、replay 重建共用）
+func NewGameState(roomID string) GameState {
+	st := GameState{
+		RoomID: roomID,
+		Phase:  PhaseLobby,
+	}
+	// 初始化座位所属队伍
+	for i := 0; i < 4; i++ {
+		st.Seats[i] = emptySeat(i)
+	}
+	// 初始化双方级牌 = 2
+	st.Teams[0].LevelRank = rules.R2
+	st.Teams[1].LevelRank = rules.R2
+
+	st.RoundIndex = 0
+	st.NextStarterSeat = 0 // 后续小局用（结算写回）
+	st.CallerSeat = -1     //
+	st.CallTurnSeat = -1   // 首局抢定主不需要turn
+	st.CallPassCount = 0
+	st.CallPassMask = 0
+	st.CallMode = CallModeRace // 首局抢定主
+	st.BottomOwnerSeat = -1
+	st.Trump.CallerSeat = -1
+	st.Config = DefaultRoomConfig()
+	return st
 }
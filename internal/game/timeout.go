@@ -0,0 +1,137 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"upgrade-lan/internal/game/rules"
+)
+
+// TurnTimers 各阶段的操作时限（秒），0 表示该阶段不计时；计时本身由 room 负责
+// 房主在大厅通过 room.settings 设置，随 GameState 落盘；未设置（GameState.Timers 为 nil）时使用服务端默认值
+type TurnTimers struct {
+	CallTrump  int `json:"callTrump"`
+	Bottom     int `json:"bottom"`
+	TrumpFight int `json:"trumpFight"`
+	PlayTrick  int `json:"playTrick"`
+	TimeBank   int `json:"timeBank"` // 棋钟：每小局每位玩家额外的可透支时间，0 表示不启用
+}
+
+// 操作时限的取值范围（秒）
+const (
+	minTurnTimer = 5
+	maxTurnTimer = 600
+	maxTimeBank  = 1800
+)
+
+func (t TurnTimers) Validate() *AppError {
+	for _, v := range []int{t.CallTrump, t.Bottom, t.TrumpFight, t.PlayTrick} {
+		if v != 0 && (v < minTurnTimer || v > maxTurnTimer) {
+			return ErrRoomBadConfig.WithInfof("操作时限需为 0（不计时）或 %d~%d 秒", minTurnTimer, maxTurnTimer)
+		}
+	}
+	if t.TimeBank < 0 || t.TimeBank > maxTimeBank {
+		return ErrRoomBadConfig.WithInfof("棋钟需为 0~%d 秒", maxTimeBank)
+	}
+	return nil
+}
+
+// ForPhase 该阶段的基础时限，0 表示不计时
+func (t TurnTimers) ForPhase(p Phase) time.Duration {
+	var sec int
+	switch p {
+	case PhaseCallTrump:
+		sec = t.CallTrump
+	case PhaseBottom:
+		sec = t.Bottom
+	case PhaseTrumpFight:
+		sec = t.TrumpFight
+	case PhasePlayTrick:
+		sec = t.PlayTrick
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// Bank 每小局的棋钟
+func (t TurnTimers) Bank() time.Duration {
+	return time.Duration(t.TimeBank) * time.Second
+}
+
+// PendingSeats 当前阶段正在等待操作的座位（计时器据此判断谁超时）
+func PendingSeats(st GameState) []int {
+	var seats []int
+	switch st.Phase {
+	case PhaseCallTrump:
+		if st.CallMode == CallModeOrdered {
+			if st.CallTurnSeat >= 0 && st.CallPassMask&(1<<uint(st.CallTurnSeat)) == 0 {
+				seats = append(seats, st.CallTurnSeat)
+			}
+			return seats
+		}
+		if st.CallerSeat >= 0 {
+			return nil
+		}
+		for i := 0; i < 4; i++ {
+			if st.CallPassMask&(1<<uint(i)) == 0 {
+				seats = append(seats, i)
+			}
+		}
+	case PhaseBottom:
+		if st.BottomOwnerSeat >= 0 {
+			seats = append(seats, st.BottomOwnerSeat)
+		}
+	case PhaseTrumpFight:
+		for i := 0; i < 4; i++ {
+			if i != st.BottomOwnerSeat && st.FightPassMask&(1<<uint(i)) == 0 {
+				seats = append(seats, i)
+			}
+		}
+	case PhasePlayTrick:
+		if st.Trick.TurnSeat >= 0 {
+			seats = append(seats, st.Trick.TurnSeat)
+		}
+	}
+	return seats
+}
+
+// TurnKey 标识“同一个等待窗口”：key 不变时计时继续，key 变化时重新计时
+func TurnKey(st GameState) string {
+	return fmt.Sprintf("%s/%d/%d/%d/%d/%d", st.Phase, st.RoundIndex, st.TrickIndex, st.CallTurnSeat, st.BottomOwnerSeat, st.Trick.TurnSeat)
+}
+
+// DefaultAction 超时托管动作：定主/攻改阶段跳过；扣底扣最小的 8 张无分牌；出牌跟最小的合法牌，先手出最小的单张
+func DefaultAction(st GameState, seat int) (ClientEventType, any, bool) {
+	if seat < 0 || seat > 3 {
+		return "", nil, false
+	}
+	hand := st.Seats[seat].Hand
+	switch st.Phase {
+	case PhaseCallTrump, PhaseTrumpFight:
+		return EvCallPass, struct{}{}, true
+
+	case PhaseBottom:
+		if seat != st.BottomOwnerSeat {
+			return "", nil, false
+		}
+		return EvPutBottom, PutBottomPayload{DiscardIDs: getIDs(rules.CheapestCards(hand, st.Trump.Trump, 8))}, true
+
+	case PhasePlayTrick:
+		tr := st.Trick
+		if tr.TurnSeat != seat || tr.Plays[seat] != nil || len(hand) == 0 {
+			return "", nil, false
+		}
+		if tr.LeaderSeat == seat {
+			return EvPlayCards, PlayCardsPayload{CardIDs: getIDs(rules.CheapestCards(hand, st.Trump.Trump, 1))}, true
+		}
+		lead := tr.Plays[tr.LeaderSeat]
+		if lead == nil {
+			return "", nil, false
+		}
+		cands, err := rules.LegalFollows(hand, lead.Blocks, st.Trump.Trump, 1)
+		if err != nil || len(cands) == 0 {
+			return "", nil, false
+		}
+		return EvPlayCards, PlayCardsPayload{CardIDs: getIDs(cands[0].Cards)}, true
+	}
+	return "", nil, false
+}
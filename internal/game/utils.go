@@ -177,16 +177,16 @@ func isLastTrickAfterThisTrick(st *GameState) bool {
 	return true
 }
 
-// canDigBottom 是否可挖底，返回值：第一个表示是否可挖底，第二个表示原因：1 非末墩赢家 2 本局无人定主 3
-func canDigBottom(st *GameState, winner int) (bool, string) {
+// canDigBottom 是否可挖底，返回值：第一个表示是否可挖底，第二个为不可挖底的原因（通知码）：末墩坐家赢 / 硬主 / 打家攻主
+func canDigBottom(st *GameState, winner int) (bool, NoticeCode) {
 	if inCallerGroup(st, winner) {
-		return false, "打家未能挖底"
+		return false, NtDigCallerWon
 	}
 	if st.BottomOwnerSeat == -1 {
-		return false, "本局硬主不可挖底"
+		return false, NtDigHardTrump
 	}
 	if !st.Trump.HasTrumpSuit && st.BottomOwnerSeat >= 0 && st.BottomOwnerSeat%2 == winner%2 {
-		return false, "打家攻主后不可挖底"
+		return false, NtDigAttacked
 	}
 	return true, ""
 }
@@ -256,3 +256,90 @@ func groupBySuitClass(cards []rules.Card) [][]rules.Card {
 	}
 	return result
 }
+
+// --- 连接状态（不经过 Reduce，但 replay 需要同样的迁移） ---
+
+// MarkOnline 连接加入：若该 uid 已入座则标记在线
+func MarkOnline(st GameState, uid string) GameState {
+	for i := 0; i < 4; i++ {
+		if st.Seats[i].UID == uid {
+			st.Seats[i].Online = true
+		}
+	}
+	return st
+}
+
+// MarkOffline 连接断开：座位标记离线并取消准备
+func MarkOffline(st GameState, uid string) GameState {
+	for i := 0; i < 4; i++ {
+		if st.Seats[i].UID == uid {
+			st.Seats[i].Online = false
+			st.Seats[i].Ready = false
+		}
+	}
+	st.Version++
+	return st
+}
+
+// checkDeclCards 本小局亮过的王牌、级牌不能再参与定主/改主/攻主
+func checkDeclCards(st *GameState, cards []rules.Card) *AppError {
+	for _, d := range st.Declarations {
+		for _, used := range d.CardIDs {
+			for _, c := range cards {
+				if c.ID == used {
+					return ErrRuleCardReused.WithInfof("%s已在%d号位的%s中亮过", cardName(c), d.Seat, declKindName(d.Kind))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// recordDeclaration 声明成功后调用（st.Trump 已更新）
+func recordDeclaration(st *GameState, seat int, kind DeclKind, cards []rules.Card) {
+	shown := make([]rules.Card, len(cards))
+	for i, c := range cards {
+		c.SuitClass = "" // 牌域随主变化，记录里只保留牌面
+		shown[i] = c
+	}
+	st.Declarations = append(st.Declarations, Declaration{
+		Seat:    seat,
+		Kind:    kind,
+		CardIDs: getIDs(cards),
+		Cards:   shown,
+		Trump:   st.Trump,
+	})
+}
+
+func cardName(c rules.Card) string {
+	if c.Suit == rules.BigJoker || c.Suit == rules.SmallJoker {
+		return string(c.Rank)
+	}
+	return string(c.Suit) + string(c.Rank)
+}
+
+func declKindName(k DeclKind) string {
+	switch k {
+	case DeclCall:
+		return "定主"
+	case DeclChange:
+		return "改主"
+	case DeclAttack:
+		return "攻主"
+	default:
+		return string(k)
+	}
+}
+
+func cloneDeclarations(ds []Declaration) []Declaration {
+	if ds == nil {
+		return nil
+	}
+	out := make([]Declaration, len(ds))
+	for i, d := range ds {
+		d.CardIDs = append([]int(nil), d.CardIDs...)
+		d.Cards = append([]rules.Card(nil), d.Cards...)
+		out[i] = d
+	}
+	return out
+}
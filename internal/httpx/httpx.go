@@ -0,0 +1,27 @@
+// Package httpx HTTP 接口（/rooms、/session、/stats、/hands）共用的响应工具
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AllowCORS 允许任意来源访问：LAN demo 中前端 dev server 与后端不同源
+// 预检请求（OPTIONS）直接回复允许的方法并返回 true，调用方随即返回
+func AllowCORS(w http.ResponseWriter, r *http.Request, methods string) bool {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodOptions {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Methods", methods)
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// WriteJSON 以 JSON 回复（错误时 v 为 *game.AppError）
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
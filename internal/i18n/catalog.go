@@ -0,0 +1,125 @@
+package i18n
+
+// catalog 通知码 -> 模板；通知码与参数见 game/notice.go
+var catalog = map[Locale]map[string]string{
+	ZhCN: {
+		"seat.sit":         "玩家{uid}已坐入{seat}号位",
+		"seat.leave":       "玩家{uid}已离开{seat}号位",
+		"deal.auto":        "所有人已准备，系统已自动发牌",
+		"deal.force":       "房主已强制开局",
+		"deal.manual":      "已手动发牌",
+		"settings.updated": "房间设置已更新",
+		"settings.profile": "房间规则已切换为 {profile}",
+		"settings.custom":  "房间规则已切换为自定义方案 {profile}",
+
+		"call.pass":         "{seat}号位不定主",
+		"call.hard":         "无人定主，本小局硬主，级牌为{rank}",
+		"call.trump":        "{seat}号位成功定主，主牌为{suit}，级牌为{rank}，请扣底牌",
+		"call.trump_locked": "{seat}号位成功定主，主牌为{suit}（已锁定），级牌为{rank}，请扣底牌",
+		"bottom.done":       "玩家{seat}完成扣牌",
+		"bottom.no_dig":     "本回合打家不可挖底",
+		"fight.pass":        "玩家{seat}已选择跳过",
+		"fight.change":      "{seat}号位改主成功，变为花色{suit}",
+		"fight.attack":      "玩家{seat}攻主成功，本小局硬主",
+		"play.start":        "进入出牌阶段，由{seat}号位先手",
+		"fight.closed":      "无人继续改/攻主，进入出牌阶段，由{seat}号位先手",
+
+		"play.cards":        "玩家{seat}已出牌",
+		"play.throw_ok":     "玩家{seat}已出牌【甩牌成功】",
+		"play.throw_fail":   "玩家{seat}已出牌【⚠️甩牌失败，原计划甩出{count}张{suitClass}⚠️】",
+		"play.pad":          "玩家{seat}已出牌【垫牌】",
+		"trick.caller":      "本墩结束，赢家:{seat}号位（坐家），共跑分:{points}，打家不得分",
+		"trick.defender":    "本墩结束，赢家:{seat}号位（打家），共吃分:{points}，打家累计分:{total}",
+		"dig.award":         "末墩抠底，底牌分={base}×{mul}，打家累计分={total}",
+		"dig.caller_won":    "打家未能挖底",
+		"dig.hard_trump":    "本局硬主不可挖底",
+		"dig.attacked":      "打家攻主后不可挖底",
+		"round.end":         "小局结束：打家得分={points}，结果={label}，坐家+{callerDelta} 打家+{defenderDelta}，下一局先手定主权=玩家{nextSeat}（需其点击开始下一局）",
+		"round.swap":        "换坐：叫主起点从{fromSeat}号位顺延到{nextSeat}号位",
+		"round.next":        "玩家{seat}开始下一小局（第{round}局），{nextSeat}号位优先定主",
+		"match.end":         "整局结束：{team}队打过A获胜，共{rounds}小局",
+		"match.rematch":     "玩家{seat}发起再来一局，双方级牌重置为2，请重新准备",
+		"error.caller_seat": "Fatal! CallerSeat非法取值:{seat}",
+
+		"host.changed":      "玩家{uid}成为房主",
+		"host.kicked":       "玩家{uid}已被房主移出房间",
+		"host.kicked_bot":   "玩家{uid}已被房主移出房间，{seat}号位由机器人代打到整局结束",
+		"clock.auto":        "玩家{uid}操作超时，系统自动代打",
+		"undo.request":      "玩家{uid}请求悔棋：撤回玩家{target}的 {action}，等待其他玩家同意",
+		"undo.rejected":     "玩家{uid}拒绝悔棋",
+		"undo.stale":        "局面已变化，悔棋请求已失效",
+		"undo.seat_changed": "座位已变化，无法悔棋",
+		"undo.done":         "全员同意，已撤回玩家{target}的 {action}",
+		"session.replaced":  "该UID在其他位置登录，你已被顶下线",
+	},
+	En: {
+		"seat.sit":         "{uid} took seat {seat}",
+		"seat.leave":       "{uid} left seat {seat}",
+		"deal.auto":        "Everyone is ready, dealing",
+		"deal.force":       "The host started the game",
+		"deal.manual":      "Dealing",
+		"settings.updated": "Room settings updated",
+		"settings.profile": "Rule profile switched to {profile}",
+		"settings.custom":  "Rule profile switched to custom profile {profile}",
+
+		"call.pass":         "Seat {seat} passed on calling trump",
+		"call.hard":         "Nobody called trump: no-trump round, level rank {rank}",
+		"call.trump":        "Seat {seat} called trump {suit}, level rank {rank}; bury the bottom",
+		"call.trump_locked": "Seat {seat} called trump {suit} (locked), level rank {rank}; bury the bottom",
+		"bottom.done":       "Seat {seat} buried the bottom",
+		"bottom.no_dig":     "Defenders cannot dig the bottom this round",
+		"fight.pass":        "Seat {seat} passed",
+		"fight.change":      "Seat {seat} changed trump to {suit}",
+		"fight.attack":      "Seat {seat} attacked trump: no-trump round",
+		"play.start":        "Play begins, seat {seat} leads",
+		"fight.closed":      "No more changes or attacks. Play begins, seat {seat} leads",
+
+		"play.cards":        "Seat {seat} played",
+		"play.throw_ok":     "Seat {seat} played [throw succeeded]",
+		"play.throw_fail":   "Seat {seat} played [⚠️ throw of {count} {suitClass} cards failed ⚠️]",
+		"play.pad":          "Seat {seat} played [discard]",
+		"trick.caller":      "Trick won by seat {seat} (callers), {points} points kept from defenders",
+		"trick.defender":    "Trick won by seat {seat} (defenders), {points} points captured, defenders total {total}",
+		"dig.award":         "Last trick digs the bottom: {base}×{mul}, defenders total {total}",
+		"dig.caller_won":    "Defenders failed to dig the bottom",
+		"dig.hard_trump":    "No bottom dig in a no-trump round",
+		"dig.attacked":      "No bottom dig after the defenders attacked trump",
+		"round.end":         "Round over: defenders scored {points}, result {label}, callers +{callerDelta} defenders +{defenderDelta}; seat {nextSeat} calls first next round (and starts it)",
+		"round.swap":        "Sides swap: first call moves from seat {fromSeat} to seat {nextSeat}",
+		"round.next":        "Seat {seat} started round {round}; seat {nextSeat} calls first",
+		"match.end":         "Match over: team {team} passed A and wins after {rounds} rounds",
+		"match.rematch":     "Seat {seat} started a rematch; both teams reset to 2, get ready again",
+		"error.caller_seat": "Fatal! invalid CallerSeat: {seat}",
+
+		"host.changed":      "{uid} is now the host",
+		"host.kicked":       "{uid} was removed by the host",
+		"host.kicked_bot":   "{uid} was removed by the host; a bot plays seat {seat} until the match ends",
+		"clock.auto":        "{uid} timed out; the system played for them",
+		"undo.request":      "{uid} asks to undo {target}'s {action}; waiting for the others",
+		"undo.rejected":     "{uid} rejected the undo",
+		"undo.stale":        "The game moved on; the undo request expired",
+		"undo.seat_changed": "Seats changed; cannot undo",
+		"undo.done":         "Everyone agreed: undid {target}'s {action}",
+		"session.replaced":  "This UID logged in elsewhere; you have been disconnected",
+	},
+}
+
+var enSuitClass = map[string]string{
+	"主牌": "trump",
+	"黑桃": "spade",
+	"红桃": "heart",
+	"梅花": "club",
+	"方块": "diamond",
+	"杂牌": "mixed",
+}
+
+// enLabels 内置方案的结算档位名称；自定义名称原样输出
+var enLabels = map[string]string{
+	"满分":   "Full score",
+	"大胜":   "Big win",
+	"过大关":  "Passed high bar",
+	"换坐":   "Swap",
+	"过小关":  "Passed low bar",
+	"不过小关": "Missed low bar",
+	"光头":   "Shutout",
+}
@@ -0,0 +1,79 @@
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Locale 连接的显示语言，握手时由 ?lang= 或 Accept-Language 决定
+type Locale string
+
+const (
+	ZhCN Locale = "zh-CN"
+	En   Locale = "en"
+
+	Default = ZhCN // 日志、未指定语言的连接
+)
+
+// Match 解析 ?lang= 或 Accept-Language（如 "en-US,en;q=0.9,zh;q=0.8"），按出现顺序取第一个支持的语言（忽略 q 权重）
+func Match(s string) Locale {
+	for _, part := range strings.Split(s, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		switch {
+		case strings.HasPrefix(tag, "zh"):
+			return ZhCN
+		case strings.HasPrefix(tag, "en"):
+			return En
+		}
+	}
+	return Default
+}
+
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// Render 按语言渲染一条通知：模板中的 {key} 替换为 params[key]
+// 未收录的通知码退回中文模板，仍未收录时原样返回通知码
+func Render(loc Locale, code string, params map[string]any) string {
+	tpl, ok := catalog[loc][code]
+	if !ok {
+		loc = Default
+		if tpl, ok = catalog[Default][code]; !ok {
+			return code
+		}
+	}
+	return placeholder.ReplaceAllStringFunc(tpl, func(m string) string {
+		key := m[1 : len(m)-1]
+		v, ok := params[key]
+		if !ok {
+			return m
+		}
+		return formatParam(loc, key, v)
+	})
+}
+
+// formatParam 牌域、结算档位名称在英文下需要翻译，其余参数原样输出
+func formatParam(loc Locale, key string, v any) string {
+	s := fmt.Sprint(v)
+	if loc != En {
+		return s
+	}
+	switch key {
+	case "suitClass":
+		if t, ok := enSuitClass[s]; ok {
+			return t
+		}
+	case "label":
+		if t, ok := enLabels[s]; ok {
+			return t
+		}
+		// ladder 方案的档位名："打家+2" / "坐家+1"
+		if n, ok := strings.CutPrefix(s, "打家+"); ok {
+			return "Defenders +" + n
+		}
+		if n, ok := strings.CutPrefix(s, "坐家+"); ok {
+			return "Callers +" + n
+		}
+	}
+	return s
+}
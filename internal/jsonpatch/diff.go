@@ -0,0 +1,90 @@
+// Package jsonpatch 生成 RFC 6902（JSON-Patch）风格的差量，仅使用 add / remove / replace 三种操作
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Op 一条 patch 操作；按顺序应用
+type Op struct {
+	Op    string          `json:"op"` // add / remove / replace
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Diff 计算把 a 变成 b 的操作序列
+// a、b 必须是 json.Unmarshal 到 any 的结果（map[string]any / []any / 标量）
+func Diff(a, b any) []Op {
+	var ops []Op
+	diff("", a, b, &ops)
+	return ops
+}
+
+func diff(path string, a, b any, ops *[]Op) {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			*ops = append(*ops, op("replace", path, b))
+			return
+		}
+		for _, k := range sortedKeys(av) {
+			if bx, ok := bv[k]; ok {
+				diff(path+"/"+escape(k), av[k], bx, ops)
+			} else {
+				*ops = append(*ops, Op{Op: "remove", Path: path + "/" + escape(k)})
+			}
+		}
+		for _, k := range sortedKeys(bv) {
+			if _, ok := av[k]; !ok {
+				*ops = append(*ops, op("add", path+"/"+escape(k), bv[k]))
+			}
+		}
+
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			*ops = append(*ops, op("replace", path, b))
+			return
+		}
+		// 公共前缀逐个比较；多出的尾部 add，缺少的尾部从后往前 remove
+		n := min(len(av), len(bv))
+		for i := 0; i < n; i++ {
+			diff(path+"/"+strconv.Itoa(i), av[i], bv[i], ops)
+		}
+		for i := n; i < len(bv); i++ {
+			*ops = append(*ops, op("add", path+"/"+strconv.Itoa(i), bv[i]))
+		}
+		for i := len(av) - 1; i >= n; i-- {
+			*ops = append(*ops, Op{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+
+	default:
+		if !reflect.DeepEqual(a, b) {
+			*ops = append(*ops, op("replace", path, b))
+		}
+	}
+}
+
+func op(kind, path string, v any) Op {
+	b, _ := json.Marshal(v) // v 来自 json.Unmarshal，必然可编码
+	return Op{Op: kind, Path: path, Value: b}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escape JSON Pointer 转义：~ -> ~0，/ -> ~1
+func escape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
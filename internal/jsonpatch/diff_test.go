@@ -0,0 +1,117 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// apply 按 frontend/src/utils/patch.ts 的语义应用 ops，返回新的根
+func apply(t *testing.T, doc any, ops []Op) any {
+	t.Helper()
+	for _, o := range ops {
+		var v any
+		if o.Op != "remove" {
+			if err := json.Unmarshal(o.Value, &v); err != nil {
+				t.Fatalf("%s %s 的 value 无法解码: %v", o.Op, o.Path, err)
+			}
+		}
+		if o.Path == "" {
+			doc = v
+			continue
+		}
+		var parts []string
+		for _, p := range strings.Split(o.Path[1:], "/") {
+			parts = append(parts, strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~"))
+		}
+		doc = applyAt(t, doc, parts, o.Op, v)
+	}
+	return doc
+}
+
+func applyAt(t *testing.T, node any, parts []string, kind string, v any) any {
+	t.Helper()
+	key, rest := parts[0], parts[1:]
+	switch n := node.(type) {
+	case map[string]any:
+		switch {
+		case len(rest) > 0:
+			n[key] = applyAt(t, n[key], rest, kind, v)
+		case kind == "remove":
+			delete(n, key)
+		default:
+			n[key] = v
+		}
+		return n
+	case []any:
+		i, err := strconv.Atoi(key)
+		if err != nil || i > len(n) {
+			t.Fatalf("数组下标 %q 越界（长度 %d）", key, len(n))
+		}
+		switch {
+		case len(rest) > 0:
+			n[i] = applyAt(t, n[i], rest, kind, v)
+		case kind == "add":
+			n = append(n[:i], append([]any{v}, n[i:]...)...)
+		case kind == "remove":
+			n = append(n[:i], n[i+1:]...)
+		default:
+			n[i] = v
+		}
+		return n
+	}
+	t.Fatalf("路径 %q 指向的不是对象或数组", key)
+	return nil
+}
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestDiffRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b string
+		ops  int // -1 表示不检查条数
+	}{
+		{"相同", `{"a":1,"b":[1,2]}`, `{"a":1,"b":[1,2]}`, 0},
+		{"标量替换", `{"a":1}`, `{"a":2}`, 1},
+		{"增删字段", `{"a":1,"b":2}`, `{"b":2,"c":3}`, 2},
+		{"数组变长", `{"h":[1,2]}`, `{"h":[1,2,3,4]}`, 2},
+		{"数组变短", `{"h":[1,2,3,4]}`, `{"h":[1]}`, 3},
+		{"数组元素内改动", `{"s":[{"uid":"a","ready":false},{"uid":"b"}]}`, `{"s":[{"uid":"a","ready":true},{"uid":"b"}]}`, 1},
+		{"类型改变", `{"t":{"x":1}}`, `{"t":[1]}`, 1},
+		{"null 与值互换", `{"a":null,"b":1}`, `{"a":[],"b":null}`, 2},
+		{"需要转义的键", `{"a/b":1,"c~d":{"e":1}}`, `{"a/b":2,"c~d":{"e":2}}`, 2},
+		{"根替换", `[1,2]`, `{"a":1}`, 1},
+		{"嵌套混合", `{"seats":[{"hand":[3,4,5]},{"hand":[]}],"v":7}`, `{"seats":[{"hand":[4]},{"hand":[9,8]}],"v":8,"trick":{"lead":0}}`, -1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ops := Diff(decode(t, tc.a), decode(t, tc.b))
+			if tc.ops >= 0 && len(ops) != tc.ops {
+				t.Errorf("Diff 给出 %d 条操作，期望 %d 条: %+v", len(ops), tc.ops, ops)
+			}
+			// 经过一次 JSON 编解码，和下发给客户端的一致
+			raw, err := json.Marshal(ops)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sent []Op
+			if err := json.Unmarshal(raw, &sent); err != nil {
+				t.Fatal(err)
+			}
+			got := apply(t, decode(t, tc.a), sent)
+			if want := decode(t, tc.b); !reflect.DeepEqual(got, want) {
+				t.Fatalf("应用 %s 后得到 %v，期望 %v", raw, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 最小化的 Prometheus 文本格式实现（不引入 client_golang）
+// - 计数器 / 直方图在事件发生时累加（并发安全）
+// - GaugeFunc 在每次抓取时回调取值（房间数、队列深度等）
+// 所有指标注册到包级 registry，由 ServeMetrics 输出
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+var registry struct {
+	mu      sync.Mutex
+	names   []string
+	metrics map[string]metric
+}
+
+// register 同名重复注册时覆盖（如测试中多次创建 Manager），保持首次注册的顺序
+func register(name string, m metric) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.metrics == nil {
+		registry.metrics = make(map[string]metric)
+	}
+	if _, ok := registry.metrics[name]; !ok {
+		registry.names = append(registry.names, name)
+	}
+	registry.metrics[name] = m
+}
+
+// Counter 无标签计数器
+type Counter struct {
+	name, help string
+	v          atomic.Uint64
+}
+
+func NewCounter(name, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	register(name, c)
+	return c
+}
+
+func (c *Counter) Inc() { c.v.Add(1) }
+
+func (c *Counter) write(w *bufio.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+	fmt.Fprintf(w, "%s %d\n", c.name, c.v.Load())
+}
+
+// CounterVec 单标签计数器
+type CounterVec struct {
+	name, help, label string
+	mu                sync.Mutex
+	vals              map[string]uint64
+}
+
+func NewCounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{name: name, help: help, label: label, vals: make(map[string]uint64)}
+	register(name, c)
+	return c
+}
+
+func (c *CounterVec) Inc(value string) {
+	c.mu.Lock()
+	c.vals[value]++
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, v := range sortedKeys(c.vals) {
+		fmt.Fprintf(w, "%s{%s} %d\n", c.name, labelPair(c.label, v), c.vals[v])
+	}
+}
+
+// HistogramVec 单标签直方图；Buckets 为升序上界（秒），+Inf 自动追加
+type HistogramVec struct {
+	name, help, label string
+	buckets           []float64
+	mu                sync.Mutex
+	vals              map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // 与 buckets 对应，非累计
+	count  uint64
+	sum    float64
+}
+
+func NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, label: label, buckets: buckets, vals: make(map[string]*histogram)}
+	register(name, h)
+	return h
+}
+
+func (h *HistogramVec) Observe(value string, d time.Duration) {
+	s := d.Seconds()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hv := h.vals[value]
+	if hv == nil {
+		hv = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.vals[value] = hv
+	}
+	for i, b := range h.buckets {
+		if s <= b {
+			hv.counts[i]++
+			break
+		}
+	}
+	hv.count++
+	hv.sum += s
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, v := range sortedKeys(h.vals) {
+		hv := h.vals[v]
+		lp := labelPair(h.label, v)
+		var cum uint64
+		for i, b := range h.buckets {
+			cum += hv.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", h.name, lp, formatFloat(b), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", h.name, lp, hv.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", h.name, lp, formatFloat(hv.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", h.name, lp, hv.count)
+	}
+}
+
+// GaugeFunc 抓取时回调取值；label 为空时 fn 返回的 map 只取 "" 键
+type GaugeFunc struct {
+	name, help, label string
+	fn                func() map[string]float64
+}
+
+func NewGaugeFunc(name, help, label string, fn func() map[string]float64) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, label: label, fn: fn}
+	register(name, g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	vals := g.fn()
+	if g.label == "" {
+		fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(vals[""]))
+		return
+	}
+	for _, v := range sortedKeys(vals) {
+		fmt.Fprintf(w, "%s{%s} %s\n", g.name, labelPair(g.label, v), formatFloat(vals[v]))
+	}
+}
+
+// ServeMetrics HTTP GET /metrics（Prometheus text format 0.0.4）
+func ServeMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	registry.mu.Lock()
+	list := make([]metric, 0, len(registry.names))
+	for _, name := range registry.names {
+		list = append(list, registry.metrics[name])
+	}
+	registry.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	for _, m := range list {
+		m.write(bw)
+	}
+	_ = bw.Flush()
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+func labelPair(label, value string) string {
+	return label + `="` + escapeLabel(value) + `"`
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
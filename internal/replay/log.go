@@ -0,0 +1,99 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/game/rules"
+)
+
+// 非客户端事件的条目类型
+const (
+	TypeOpen    game.ClientEventType = "replay.open"    // 房间（重新）创建，state 从 NewGameState 开始
+	TypeRestore game.ClientEventType = "replay.restore" // 服务重启后从数据目录恢复，payload 为 game.MarshalFull
+	TypeUndo    game.ClientEventType = "replay.undo"    // 悔棋撤回，payload 为撤回后的 game.MarshalFull
+	TypeJoin    game.ClientEventType = "conn.join"      // 连接加入 -> game.MarkOnline
+	TypeLeave   game.ClientEventType = "conn.leave"     // 连接断开 -> game.MarkOffline
+)
+
+// Entry replay log 中的一行
+type Entry struct {
+	Time    time.Time            `json:"ts"`
+	RoomID  string               `json:"roomId,omitempty"` // 仅 TypeOpen
+	UID     string               `json:"uid,omitempty"`
+	Type    game.ClientEventType `json:"type"`
+	Payload json.RawMessage      `json:"payload,omitempty"` // 客户端原始 payload
+	Version int64                `json:"version"`           // 处理后的 GameState.Version
+	Seed    *rules.Seed          `json:"seed,omitempty"`    // 本事件触发发牌时的种子
+}
+
+// Writer 每个房间一个 append-only 的 JSONL 文件
+type Writer struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+// Path 房间日志路径，roomID 做转义防止越出目录
+func Path(dir, roomID string) string {
+	return filepath.Join(dir, url.PathEscape(roomID)+".jsonl")
+}
+
+func Open(dir, roomID string) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(Path(dir, roomID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (w *Writer) Append(e Entry) error {
+	if w == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	return w.enc.Encode(e)
+}
+
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.f.Close()
+}
+
+// ReadFile 读取整个 replay log
+func ReadFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("第%d行解析失败: %w", line, err)
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
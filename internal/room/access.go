@@ -0,0 +1,208 @@
+package room
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/transport"
+)
+
+const (
+	maxPasswordLen = 64
+	maxInvites     = 20
+)
+
+// Invite 一次性邀请：被使用一次或过期后失效
+type Invite struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// roomAccess 私密房间的准入控制
+// Manager.OnConnect 在 Room.Join 之前调用 admit，运行在连接的 goroutine 上，因此单独加锁
+type roomAccess struct {
+	mu       sync.Mutex
+	salt     []byte
+	hash     []byte               // 为空表示无密码
+	invites  map[string]time.Time // token -> 过期时间
+	private  bool                 // 创建时设置了密码或邀请
+	admitted map[string]bool      // 已准入的 uid：重连不需要再次输入密码/邀请
+	kicked   map[string]bool      // 被房主移出的 uid：公开房间也不能再加入
+	rev      int                  // 每次变化递增，用于判断是否需要落盘
+}
+
+// accessSnapshot roomAccess 的落盘格式
+type accessSnapshot struct {
+	Salt     []byte               `json:"salt,omitempty"`
+	Hash     []byte               `json:"hash,omitempty"`
+	Invites  map[string]time.Time `json:"invites,omitempty"`
+	Private  bool                 `json:"private"`
+	Admitted []string             `json:"admitted,omitempty"`
+	Kicked   []string             `json:"kicked,omitempty"`
+}
+
+func newRoomAccess() *roomAccess {
+	return &roomAccess{invites: make(map[string]time.Time), admitted: make(map[string]bool), kicked: make(map[string]bool)}
+}
+
+// setup 创建房间时设置密码并生成 n 个邀请
+func (a *roomAccess) setup(password string, n int, ttl time.Duration) []Invite {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if password != "" {
+		a.salt = randomBytes(16)
+		a.hash = hashPassword(a.salt, password)
+		a.private = true
+	}
+	out := make([]Invite, 0, n)
+	now := time.Now()
+	for i := 0; i < n; i++ {
+		inv := Invite{Token: base64.RawURLEncoding.EncodeToString(randomBytes(16)), ExpiresAt: now.Add(ttl)}
+		a.invites[inv.Token] = inv.ExpiresAt
+		out = append(out, inv)
+	}
+	if n > 0 {
+		a.private = true
+	}
+	a.rev++
+	return out
+}
+
+// admit 校验准入：公开房间、已准入的 uid、密码正确或邀请有效（邀请随即作废）
+func (a *roomAccess) admit(uid string, cred transport.Credentials) *game.AppError {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.kicked[uid] {
+		return game.ErrRoomKicked
+	}
+	if !a.private || a.admitted[uid] {
+		return nil
+	}
+
+	if cred.Invite != "" {
+		exp, ok := a.invites[cred.Invite]
+		if ok {
+			delete(a.invites, cred.Invite)
+			a.rev++
+		}
+		if ok && time.Now().Before(exp) {
+			a.admitted[uid] = true
+			return nil
+		}
+		if len(a.hash) == 0 || cred.Password == "" {
+			return game.ErrRoomInviteInvalid
+		}
+	}
+
+	if len(a.hash) == 0 {
+		return game.ErrRoomInviteOnly
+	}
+	if cred.Password == "" {
+		return game.ErrRoomPasswordRequired
+	}
+	if subtle.ConstantTimeCompare(hashPassword(a.salt, cred.Password), a.hash) != 1 {
+		return game.ErrRoomBadPassword
+	}
+	a.admitted[uid] = true
+	a.rev++
+	return nil
+}
+
+// grant 直接准入该 uid（创建私密房间的房主不需要密码或邀请）
+func (a *roomAccess) grant(uid string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.admitted[uid] = true
+	a.rev++
+}
+
+// kick 禁止该 uid 再次加入
+func (a *roomAccess) kick(uid string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.kicked[uid] = true
+	delete(a.admitted, uid)
+	a.rev++
+}
+
+func (a *roomAccess) isKicked(uid string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.kicked[uid]
+}
+
+// member 公开房间任何人都是成员；私密房间需已准入（牌谱下载等 HTTP 接口使用）
+func (a *roomAccess) member(uid string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.kicked[uid] {
+		return false
+	}
+	return !a.private || a.admitted[uid]
+}
+
+func (a *roomAccess) locked() (password bool, inviteOnly bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.hash) > 0, a.private && len(a.hash) == 0
+}
+
+func (a *roomAccess) revision() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rev
+}
+
+func (a *roomAccess) snapshot() accessSnapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := accessSnapshot{Salt: a.salt, Hash: a.hash, Private: a.private, Invites: make(map[string]time.Time, len(a.invites))}
+	now := time.Now()
+	for t, exp := range a.invites {
+		if now.Before(exp) {
+			s.Invites[t] = exp
+		}
+	}
+	for uid := range a.admitted {
+		s.Admitted = append(s.Admitted, uid)
+	}
+	for uid := range a.kicked {
+		s.Kicked = append(s.Kicked, uid)
+	}
+	return s
+}
+
+func (a *roomAccess) restore(s accessSnapshot) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.salt, a.hash, a.private = s.Salt, s.Hash, s.Private
+	for t, exp := range s.Invites {
+		a.invites[t] = exp
+	}
+	for _, uid := range s.Admitted {
+		a.admitted[uid] = true
+	}
+	for _, uid := range s.Kicked {
+		a.kicked[uid] = true
+	}
+}
+
+func hashPassword(salt []byte, password string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(password))
+	return h.Sum(nil)
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
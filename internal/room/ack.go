@@ -0,0 +1,45 @@
+package room
+
+import "upgrade-lan/internal/game"
+
+// ackWindowSize 每个 uid 保留最近处理过的 reqId 数量
+const ackWindowSize = 64
+
+// ackWindow 单个 uid 最近处理过的 reqId -> 回执（FIFO 淘汰）
+type ackWindow struct {
+	order []string
+	byReq map[string]game.AckMsg
+}
+
+func newAckWindow() *ackWindow {
+	return &ackWindow{
+		order: make([]string, 0, ackWindowSize),
+		byReq: make(map[string]game.AckMsg, ackWindowSize),
+	}
+}
+
+func (w *ackWindow) get(reqID string) (game.AckMsg, bool) {
+	ack, ok := w.byReq[reqID]
+	return ack, ok
+}
+
+func (w *ackWindow) put(ack game.AckMsg) {
+	if _, ok := w.byReq[ack.ReqID]; ok {
+		w.byReq[ack.ReqID] = ack
+		return
+	}
+	if len(w.order) >= ackWindowSize {
+		oldest := w.order[0]
+		w.order = w.order[1:]
+		delete(w.byReq, oldest)
+	}
+	w.order = append(w.order, ack.ReqID)
+	w.byReq[ack.ReqID] = ack
+}
+
+// dropAcks uid 的最后一个连接离开且不在座位上时丢弃其去重窗口（断线重连的玩家仍保留）
+func (r *Room) dropAcks(uid string) {
+	if !r.connected(uid) && r.seatOf(uid) < 0 {
+		delete(r.acks, uid)
+	}
+}
@@ -0,0 +1,153 @@
+package room
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"upgrade-lan/internal/bot"
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/i18n"
+	"upgrade-lan/internal/transport"
+)
+
+// 房间级命令：不进入 game.Reduce，由 room 自己处理
+const (
+	CmdAddBot    = "room.add_bot"    // payload: {"seat": n}，在空座位放一个机器人
+	CmdRemoveBot = "room.remove_bot" // payload: {"seat": n}，让该座位的机器人离座（仅大厅）
+)
+
+// botThinkDelay 机器人每步操作前的停顿，便于真人看清
+const botThinkDelay = 600 * time.Millisecond
+
+// silentClient 机器人/系统代操作的“连接”：只有 uid，不接收任何消息（机器人通过 MakeView 看状态）
+type silentClient struct {
+	uid    string
+	roomID string
+}
+
+func (b silentClient) UID() string     { return b.uid }
+func (b silentClient) RoomID() string  { return b.roomID }
+func (b silentClient) Spectator() bool { return false }
+func (b silentClient) Credentials() transport.Credentials {
+	return transport.Credentials{}
+}
+func (b silentClient) Locale() string       { return string(i18n.Default) }
+func (b silentClient) SendJSON(v any) error { return nil }
+func (b silentClient) Close() error         { return nil }
+
+func (r *Room) addBot(c transport.Client, raw json.RawMessage) *game.AppError {
+	if err := r.requireHost(c); err != nil {
+		return err
+	}
+	var p game.SitPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return game.ErrBadJSON.WithInfo("添加机器人请求解析错误")
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if uid := r.state.Seats[p.Seat].UID; uid != "" {
+		return game.ErrStateSeatTaken.WithInfof("该座位已有玩家%s", uid)
+	}
+	uid := r.newBotUID()
+	// 机器人入座同样走 room.sit -> Reduce
+	if err := r.applyEvent(silentClient{uid: uid, roomID: r.id}, string(game.EvSit), raw); err != nil {
+		return err
+	}
+	r.bots[uid] = bot.NewSimple()
+	r.scheduleBots()
+	return nil
+}
+
+func (r *Room) removeBot(c transport.Client, raw json.RawMessage) *game.AppError {
+	if err := r.requireHost(c); err != nil {
+		return err
+	}
+	var p game.SitPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return game.ErrBadJSON.WithInfo("移除机器人请求解析错误")
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	uid := r.state.Seats[p.Seat].UID
+	if _, ok := r.bots[uid]; !ok {
+		return game.ErrInvalidPayload.WithInfof("%d号位不是机器人", p.Seat)
+	}
+	if err := r.applyEvent(silentClient{uid: uid, roomID: r.id}, string(game.EvLeave), json.RawMessage("{}")); err != nil {
+		return err
+	}
+	delete(r.bots, uid)
+	return nil
+}
+
+// applySystemAction 以某个 uid 的身份提交事件（机器人、超时托管），与真人同样经过 ParseClientEvent + Reduce；
+// notices 只在 Reduce 成功时排在本次结果的 notice 之前下发，被拒绝则丢弃
+func (r *Room) applySystemAction(uid string, typ game.ClientEventType, payload any, notices ...game.Notice) *game.AppError {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return game.ErrSystem.WithInfof("payload 编码失败: %v", err)
+	}
+	r.sysNotices = notices
+	defer func() { r.sysNotices = nil }()
+	return r.applyEvent(silentClient{uid: uid, roomID: r.id}, string(typ), raw)
+}
+
+func (r *Room) newBotUID() string {
+	for {
+		r.botSeq++
+		uid := fmt.Sprintf("bot-%d", r.botSeq)
+		taken := false
+		for i := 0; i < 4; i++ {
+			if r.state.Seats[i].UID == uid {
+				taken = true
+			}
+		}
+		for c := range r.conns {
+			if c.UID() == uid {
+				taken = true
+			}
+		}
+		if !taken {
+			return uid
+		}
+	}
+}
+
+// scheduleBots 状态变化后，延迟唤醒一次机器人
+func (r *Room) scheduleBots() {
+	if len(r.bots) == 0 || r.botPending {
+		return
+	}
+	r.botPending = true
+	time.AfterFunc(botThinkDelay, func() {
+		select {
+		case r.botTick <- struct{}{}:
+		default:
+		}
+	})
+}
+
+// stepBots 每次唤醒最多让一个机器人操作一步，成功后继续调度
+func (r *Room) stepBots() {
+	r.botPending = false
+	for i := 0; i < 4; i++ {
+		uid := r.state.Seats[i].UID
+		b, ok := r.bots[uid]
+		if !ok {
+			continue
+		}
+		typ, payload, ok := b.Decide(game.MakeView(r.state, uid))
+		if !ok {
+			continue
+		}
+		if err := r.applySystemAction(uid, typ, payload); err != nil {
+			slog.Warn("机器人操作被拒绝", "uid", uid, "type", typ, "err", err.Error())
+			continue
+		}
+		r.scheduleBots()
+		return
+	}
+}
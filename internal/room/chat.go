@@ -0,0 +1,115 @@
+package room
+
+import (
+	"strings"
+	"time"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/transport"
+)
+
+const (
+	chatHistorySize = 50              // 保留最近多少条，新连接加入时补发
+	chatBurst       = 5               // 令牌桶容量：允许连发的条数
+	chatRefill      = 2 * time.Second // 每隔多久恢复一条
+)
+
+// chatBucket 每个 uid 的发言令牌桶
+type chatBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *chatBucket) take(now time.Time) bool {
+	b.tokens = min(chatBurst, b.tokens+float64(now.Sub(b.last))/float64(chatRefill))
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// chat 处理 room.chat / room.emote：只广播和记录历史，不经过 Reduce，不改变 Version
+func (r *Room) chat(c transport.Client, typ game.ClientEventType, payload any) *game.AppError {
+	seat := -1
+	if !c.Spectator() {
+		seat = r.seatOf(c.UID())
+	}
+
+	msg := game.ChatMsg{Type: "chat", UID: c.UID(), Seat: seat}
+	switch p := payload.(type) {
+	case game.ChatPayload:
+		msg.Kind, msg.Text, msg.Audience = "text", strings.TrimSpace(p.Text), p.Audience
+	case game.EmotePayload:
+		msg.Kind, msg.Emote, msg.Text, msg.Audience = "emote", p.Emote, game.Emotes[p.Emote], p.Audience
+	default:
+		return game.ErrInvalidPayload.WithInfof("非法聊天事件 %s", typ)
+	}
+	if msg.Audience == "" {
+		msg.Audience = game.AudienceAll
+		if c.Spectator() {
+			msg.Audience = game.AudienceSpectators
+		}
+	}
+
+	switch {
+	case c.Spectator() && msg.Audience != game.AudienceSpectators:
+		return game.ErrStateSpectator.WithInfo("观战者只能在观战频道发言")
+	case !c.Spectator() && msg.Audience == game.AudienceSpectators:
+		return game.ErrInvalidPayload.WithInfo("只有观战者可以在观战频道发言")
+	case msg.Audience == game.AudienceSeated && seat < 0:
+		return game.ErrStateNotSeated.WithInfo("入座后才能在玩家频道发言")
+	}
+
+	now := time.Now()
+	b := r.chatLimits[c.UID()]
+	if b == nil {
+		b = &chatBucket{tokens: chatBurst, last: now}
+		r.chatLimits[c.UID()] = b
+	}
+	if !b.take(now) {
+		return game.ErrRoomChatRate
+	}
+
+	r.chatSeq++
+	msg.Seq = r.chatSeq
+	msg.Time = now.UnixMilli()
+	r.chatHistory = append(r.chatHistory, msg)
+	if n := len(r.chatHistory); n > chatHistorySize {
+		r.chatHistory = append([]game.ChatMsg(nil), r.chatHistory[n-chatHistorySize:]...)
+	}
+
+	for conn := range r.conns {
+		if r.canSeeChat(conn, msg.Audience) {
+			_ = conn.SendJSON(msg)
+		}
+	}
+	return nil
+}
+
+// canSeeChat 频道可见性按接收时的身份判断
+func (r *Room) canSeeChat(c transport.Client, a game.ChatAudience) bool {
+	switch a {
+	case game.AudienceSeated:
+		return !c.Spectator() && r.seatOf(c.UID()) >= 0
+	case game.AudienceSpectators:
+		return c.Spectator()
+	default:
+		return true
+	}
+}
+
+// sendChatHistory 新连接加入时补发可见的历史聊天
+func (r *Room) sendChatHistory(c transport.Client) {
+	msgs := make([]game.ChatMsg, 0, len(r.chatHistory))
+	for _, m := range r.chatHistory {
+		if r.canSeeChat(c, m.Audience) {
+			msgs = append(msgs, m)
+		}
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	_ = c.SendJSON(game.ChatHistoryMsg{Type: "chat_history", Messages: msgs})
+}
@@ -0,0 +1,162 @@
+package room
+
+import (
+	"log/slog"
+	"time"
+
+	"upgrade-lan/internal/game"
+)
+
+// clockRetry 托管动作失败后隔多久再试（期间玩家仍可自己操作）
+const clockRetry = 5 * time.Second
+
+// turnTimers 本房间生效的时限：房主设置过则用房间设置，否则用服务端默认值
+func (r *Room) turnTimers() game.TurnTimers {
+	if t := r.state.Timers; t != nil {
+		return *t
+	}
+	return r.timers
+}
+
+// turnClock 房间当前的等待窗口
+type turnClock struct {
+	key      string    // game.TurnKey，变化即重新计时
+	deadline time.Time // 本窗口基础时限（不含棋钟），零值表示不计时
+	seats    []int     // 等待中的座位
+	bank     [4]time.Duration
+	retry    [4]time.Time // 托管失败的座位在此之前不再重试
+	timer    *time.Timer
+	gen      int // 过期的 timer 回调按 gen 忽略
+}
+
+// decorateClock 把时限写入 view（计时属于房间，不在 GameState 里）
+func (r *Room) decorateClock(v *game.ViewState) {
+	v.Timers = r.turnTimers()
+	if r.clock.deadline.IsZero() {
+		return
+	}
+	v.Deadline = r.clock.deadline.UnixMilli()
+	if v.Timers.TimeBank > 0 {
+		for i := 0; i < 4; i++ {
+			v.Seats[i].TimeBankMs = r.clock.bank[i].Milliseconds()
+		}
+	}
+}
+
+// chargeOvertime 玩家在基础时限之后才操作：从其棋钟里扣除超出部分
+func (r *Room) chargeOvertime(uid string) {
+	if r.clock.deadline.IsZero() {
+		return
+	}
+	over := time.Since(r.clock.deadline)
+	if over <= 0 {
+		return
+	}
+	for _, s := range r.clock.seats {
+		if r.state.Seats[s].UID == uid {
+			r.clock.bank[s] = max(r.clock.bank[s]-over, 0)
+		}
+	}
+}
+
+// syncClock 每次状态变化后调用：同一等待窗口内继续计时，新窗口重新计时
+func (r *Room) syncClock(prevPhase game.Phase) {
+	st := r.state
+	timers := r.turnTimers()
+	// 新的一小局：棋钟回满
+	if st.Phase == game.PhaseCallTrump && prevPhase != game.PhaseCallTrump {
+		for i := range r.clock.bank {
+			r.clock.bank[i] = timers.Bank()
+		}
+	}
+
+	d := timers.ForPhase(st.Phase)
+	seats := game.PendingSeats(st)
+	if d == 0 || len(seats) == 0 {
+		r.clock.key = ""
+		r.clock.deadline = time.Time{}
+		r.clock.seats = nil
+		r.stopClockTimer()
+		return
+	}
+	if key := game.TurnKey(st); key != r.clock.key {
+		r.clock.key = key
+		r.clock.deadline = time.Now().Add(d)
+		r.clock.retry = [4]time.Time{}
+	}
+	r.clock.seats = seats
+	r.armClockTimer()
+}
+
+func (r *Room) stopClockTimer() {
+	r.clock.gen++
+	if r.clock.timer != nil {
+		r.clock.timer.Stop()
+		r.clock.timer = nil
+	}
+}
+
+// armClockTimer 定时到最早一个座位的最终时限（基础时限 + 该座位棋钟）
+func (r *Room) armClockTimer() {
+	r.stopClockTimer()
+	next := time.Time{}
+	for _, s := range r.clock.seats {
+		at := r.clock.dueAt(s)
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	gen := r.clock.gen
+	r.clock.timer = time.AfterFunc(time.Until(next), func() {
+		select {
+		case r.clockC <- gen:
+		case <-r.quit:
+		}
+	})
+}
+
+// dueAt 座位的最终时限（基础时限 + 棋钟），托管失败后推迟到重试时间
+func (c *turnClock) dueAt(seat int) time.Time {
+	at := c.deadline.Add(c.bank[seat])
+	if c.retry[seat].After(at) {
+		return c.retry[seat]
+	}
+	return at
+}
+
+// onClock 时限到：对已超时的座位注入系统托管动作（同样经过 Reduce）
+func (r *Room) onClock(gen int) {
+	if gen != r.clock.gen || r.clock.deadline.IsZero() {
+		return
+	}
+	now := time.Now()
+	for _, s := range append([]int(nil), r.clock.seats...) {
+		if now.Before(r.clock.dueAt(s)) {
+			continue
+		}
+		r.clock.bank[s] = 0
+		if !r.autoAct(s) {
+			r.clock.retry[s] = now.Add(clockRetry)
+		}
+	}
+	// 成功的托管会经 syncClock 重新定时；仍有座位在等（含托管失败待重试的）时保证 timer 不断
+	if !r.clock.deadline.IsZero() {
+		r.armClockTimer()
+	}
+}
+
+// autoAct 对超时座位提交默认动作（不叫/最小的单张或合法跟牌/最便宜的底牌），成功后才通知超时托管
+func (r *Room) autoAct(seat int) bool {
+	uid := r.state.Seats[seat].UID
+	typ, payload, ok := game.DefaultAction(r.state, seat)
+	if !ok {
+		slog.Warn("超时座位没有可用的托管动作", "room", r.id, "uid", uid, "phase", r.state.Phase)
+		return false
+	}
+	notice := game.NewNotice(game.NtClockAuto, game.NoticeParams{"uid": uid})
+	if err := r.applySystemAction(uid, typ, payload, notice); err != nil {
+		slog.Warn("超时托管操作被拒绝", "room", r.id, "uid", uid, "type", typ, "err", err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package room
+
+import (
+	"encoding/json"
+	"time"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/jsonpatch"
+	"upgrade-lan/internal/transport"
+)
+
+// CmdSync 协商快照模式并立即全量重发（payload: {"delta": true}）
+// 客户端发现 delta 的 baseVersion 与本地版本不一致时，再发一次即可重新同步
+const CmdSync = "room.sync"
+
+type SyncPayload struct {
+	Delta bool `json:"delta"` // true：后续按差量下发；false：恢复为每次全量
+}
+
+// deltaResendDelay 发送队列满而丢弃的 view 隔多久重发
+const deltaResendDelay = 500 * time.Millisecond
+
+// deltaBase 某个连接上一次成功入队的 view（已按 JSON 解码为通用结构），作为下次差量的基准
+// ws 连接内消息有序，入队的消息要么按序送达、要么连接断开；被丢弃的消息客户端收不到，
+// 所以丢弃时保留原基准，客户端仍停在 version，重发时直接基于它算差量
+type deltaBase struct {
+	version int64
+	doc     any  // nil 表示下一次必须全量
+	stale   bool // 最近一次下发被丢弃，等待 resendStale 补发
+}
+
+func (r *Room) sync(c transport.Client, raw json.RawMessage) *game.AppError {
+	var p SyncPayload
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return game.ErrBadJSON.WithInfo("同步请求解析错误")
+		}
+	}
+	if p.Delta {
+		r.deltas[c] = &deltaBase{}
+	} else {
+		delete(r.deltas, c)
+	}
+	if sp, ok := r.spectators[c]; ok {
+		r.pushSpectator(c, sp, true)
+		return nil
+	}
+	view := game.MakeView(r.state, c.UID())
+	r.decorateRoom(&view)
+	r.decorateClock(&view)
+	r.sendView(c, view)
+	return nil
+}
+
+// sendView 下发 view：未开启差量的连接发全量，差量比全量还大时也退回全量；发送被丢弃则稍后补发
+func (r *Room) sendView(c transport.Client, view game.ViewState) {
+	d := r.deltas[c]
+	if d == nil {
+		_ = c.SendJSON(game.Snapshot{Type: "snapshot", State: view})
+		return
+	}
+
+	full, err := json.Marshal(game.Snapshot{Type: "snapshot", State: view})
+	if err != nil {
+		_ = c.SendJSON(game.NewErrorMsg(game.ErrSystem.WithInfof("快照编码失败: %v", err), "", ""))
+		return
+	}
+	var doc struct {
+		State any `json:"state"`
+	}
+	_ = json.Unmarshal(full, &doc)
+
+	msg := json.RawMessage(full)
+	if d.doc != nil {
+		ops := jsonpatch.Diff(d.doc, doc.State)
+		if len(ops) == 0 {
+			d.stale = false
+			return
+		}
+		delta, err := json.Marshal(game.DeltaMsg{Type: "delta", BaseVersion: d.version, Version: view.Version, Ops: ops})
+		if err == nil && len(delta) < len(full) {
+			msg = delta
+		}
+	}
+
+	if err := c.SendJSON(msg); err != nil {
+		d.stale = true
+		r.scheduleResend()
+		return
+	}
+	d.version = view.Version
+	d.doc = doc.State
+	d.stale = false
+}
+
+func (r *Room) scheduleResend() {
+	if r.resendPending {
+		return
+	}
+	r.resendPending = true
+	time.AfterFunc(deltaResendDelay, func() {
+		select {
+		case r.resendC <- struct{}{}:
+		case <-r.quit:
+		}
+	})
+}
+
+// resendStale 给下发被丢弃的差量连接补发当前 view（差量基于客户端实际持有的版本）
+func (r *Room) resendStale() {
+	r.resendPending = false
+	for c, d := range r.deltas {
+		if !d.stale {
+			continue
+		}
+		if sp, ok := r.spectators[c]; ok {
+			r.pushSpectator(c, sp, true)
+			continue
+		}
+		view := game.MakeView(r.state, c.UID())
+		r.decorateRoom(&view)
+		r.decorateClock(&view)
+		r.sendView(c, view)
+	}
+}
@@ -0,0 +1,112 @@
+package room
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/i18n"
+	"upgrade-lan/internal/transport"
+)
+
+// fakeClient 记录收到的快照/差量；drop 为 true 时模拟发送队列已满
+type fakeClient struct {
+	uid  string
+	drop bool
+	got  []game.DeltaMsg // 快照记为 Type "snapshot"、只填 Version
+}
+
+func (f *fakeClient) UID() string                        { return f.uid }
+func (f *fakeClient) RoomID() string                     { return "t" }
+func (f *fakeClient) Spectator() bool                    { return false }
+func (f *fakeClient) Credentials() transport.Credentials { return transport.Credentials{} }
+func (f *fakeClient) Locale() string                     { return string(i18n.Default) }
+func (f *fakeClient) Close() error                       { return nil }
+
+func (f *fakeClient) SendJSON(v any) error {
+	if f.drop {
+		return errors.New("send queue full")
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var msg struct {
+		game.DeltaMsg
+		State struct {
+			Version int64 `json:"version"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return err
+	}
+	switch msg.Type {
+	case "snapshot":
+		f.got = append(f.got, game.DeltaMsg{Type: "snapshot", Version: msg.State.Version})
+	case "delta":
+		f.got = append(f.got, msg.DeltaMsg)
+	}
+	return nil
+}
+
+func (f *fakeClient) last(t *testing.T) game.DeltaMsg {
+	t.Helper()
+	if len(f.got) == 0 {
+		t.Fatal("没有收到任何快照")
+	}
+	return f.got[len(f.got)-1]
+}
+
+func sit(t *testing.T, r *Room, uid string, seat int) {
+	t.Helper()
+	if err := r.applySystemAction(uid, game.EvSit, game.SitPayload{Seat: seat}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 丢弃的下发不改变差量基准：之后的差量仍基于客户端实际持有的版本，而不是退回全量
+func TestDeltaBaseSurvivesDroppedSend(t *testing.T) {
+	r := NewRoom("t", Options{})
+	c := &fakeClient{uid: "watcher"}
+	r.conns[c] = struct{}{}
+	if err := r.sync(c, json.RawMessage(`{"delta":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	held := c.last(t).Version
+
+	steps := []struct {
+		name   string
+		drop   bool
+		action func()
+	}{
+		{"入座被丢弃", true, func() { sit(t, r, "a", 0) }},
+		{"下一次变化", false, func() { sit(t, r, "b", 1) }},
+		{"再次丢弃", true, func() { sit(t, r, "c", 2) }},
+		{"定时补发", false, r.resendStale},
+		{"补发后继续差量", false, func() { sit(t, r, "d", 3) }},
+	}
+	for _, s := range steps {
+		c.drop = s.drop
+		before := len(c.got)
+		s.action()
+		d := r.deltas[c]
+		if s.drop {
+			if len(c.got) != before || !d.stale || !r.resendPending {
+				t.Fatalf("%s：应标记待补发（stale=%v pending=%v）", s.name, d.stale, r.resendPending)
+			}
+			if d.version != held || d.doc == nil {
+				t.Fatalf("%s：差量基准被改动为 %d（客户端仍在 %d）", s.name, d.version, held)
+			}
+			continue
+		}
+		m := c.last(t)
+		if len(c.got) == before || m.Type != "delta" || m.BaseVersion != held || m.Version != r.state.Version {
+			t.Fatalf("%s：收到 %+v，期望基于 %d 到 %d 的差量", s.name, m, held, r.state.Version)
+		}
+		if d.stale {
+			t.Fatalf("%s：补发成功后仍标记为 stale", s.name)
+		}
+		held = m.Version
+	}
+}
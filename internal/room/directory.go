@@ -0,0 +1,50 @@
+package room
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/httpx"
+)
+
+// ServeRooms HTTP /rooms
+// - GET  房间目录：阶段、座位、两队级牌、观战人数
+// - POST 创建房间，body 可选 {"id": "...", "password": "...", "invites": n}
+func (m *Manager) ServeRooms(w http.ResponseWriter, r *http.Request) {
+	if httpx.AllowCORS(w, r, "GET, POST") {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		httpx.WriteJSON(w, http.StatusOK, m.List())
+
+	case http.MethodPost:
+		var req CreateOptions
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				httpx.WriteJSON(w, http.StatusBadRequest, game.ErrBadJSON.WithInfo("创建房间请求解析错误"))
+				return
+			}
+		}
+		q := r.URL.Query()
+		if m.opts.Authenticate != nil && (q.Has("token") || q.Has("uid")) {
+			if uid, err := m.opts.Authenticate(r); err == nil {
+				req.Host = uid
+			}
+		}
+		info, err := m.Create(req)
+		if err != nil {
+			status := http.StatusBadRequest
+			if err.Code == game.ErrRoomExists.Code {
+				status = http.StatusConflict
+			}
+			httpx.WriteJSON(w, status, err)
+			return
+		}
+		httpx.WriteJSON(w, http.StatusCreated, info)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,145 @@
+package room
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/httpx"
+)
+
+// 牌谱：记录当前小局的 Reduce 输入，小局结算后归档，供 GET /hands 下载
+// 只保存在内存中，服务重启后清空（恢复时进行中的小局不再记录）
+const maxHands = 20 // 每个房间保留最近的小局数
+
+// handLog 进行中的小局：发牌后的 state + 之后被接受的操作
+type handLog struct {
+	dealt game.GameState
+	steps []game.HandStep
+}
+
+// handMark 某次操作之前的牌谱进度，悔棋时据此回退
+type handMark struct {
+	seq   int // 已归档的小局总数
+	steps int // 进行中小局的操作数，-1 表示当前没有进行中的小局
+}
+
+func (r *Room) handMark() handMark {
+	m := handMark{seq: r.handSeq, steps: -1}
+	if r.hand != nil {
+		m.steps = len(r.hand.steps)
+	}
+	return m
+}
+
+// recordHand state 被某次操作改变后调用：发牌时开始新的小局，小局结算时归档
+func (r *Room) recordHand(prev *game.GameState, uid string, typ game.ClientEventType, payload any) {
+	switch {
+	case r.state.Phase == game.PhaseCallTrump && (prev.Phase == game.PhaseLobby || prev.Phase == game.PhaseRoundSettle):
+		r.hand = &handLog{dealt: r.state.Clone()}
+	case r.hand != nil:
+		r.hand.steps = append(r.hand.steps, game.HandStep{UID: uid, Type: typ, Payload: payload})
+		if r.state.Phase == game.PhaseRoundSettle || r.state.Phase == game.PhaseGameOver {
+			r.archiveHand()
+		}
+	}
+}
+
+// archiveHand 归档的 HandHistory 不再修改，HTTP goroutine 直接读取
+func (r *Room) archiveHand() {
+	old := r.handHistory()
+	list := make([]game.HandHistory, 0, maxHands)
+	list = append(list, old[max(0, len(old)-maxHands+1):]...)
+	list = append(list, game.HandHistory{Dealt: r.hand.dealt, Steps: r.hand.steps})
+	r.hands.Store(&list)
+	r.handSeq++
+	r.hand = nil
+}
+
+// rewindHand 悔棋后回退牌谱；撤回的是小局最后一手时，从归档中取回该小局
+func (r *Room) rewindHand(m handMark) {
+	if r.handSeq > m.seq {
+		old := r.handHistory()
+		last := old[len(old)-1]
+		list := append([]game.HandHistory(nil), old[:len(old)-1]...)
+		r.hands.Store(&list)
+		r.handSeq = m.seq
+		// 复制 steps：旧的归档可能正被 HTTP goroutine 读取
+		r.hand = &handLog{dealt: last.Dealt, steps: append([]game.HandStep(nil), last.Steps...)}
+	}
+	if m.steps < 0 {
+		r.hand = nil
+		return
+	}
+	if r.hand != nil && m.steps <= len(r.hand.steps) {
+		r.hand.steps = r.hand.steps[:m.steps]
+	}
+}
+
+// handHistory 已归档的小局，从旧到新（并发安全）
+func (r *Room) handHistory() []game.HandHistory {
+	if p := r.hands.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// ServeHands HTTP GET /hands?room=<id>[&round=<n>]
+// 下载最近一个已结束小局的牌谱；round 为小局序号（从0开始），同一序号取最近的一次
+// 私密房间仅限已准入的成员（带 ?token= 或 -open-lan 下的 ?uid=）
+func (m *Manager) ServeHands(w http.ResponseWriter, r *http.Request) {
+	if httpx.AllowCORS(w, r, "GET") {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	var rm *Room
+	m.withRoom(q.Get("room"), func(x *Room) { rm = x })
+	if rm == nil {
+		httpx.WriteJSON(w, http.StatusNotFound, game.ErrRoomNotFound)
+		return
+	}
+	uid := ""
+	if m.opts.Authenticate != nil {
+		if u, err := m.opts.Authenticate(r); err == nil {
+			uid = u
+		}
+	}
+	if !rm.access.member(uid) {
+		httpx.WriteJSON(w, http.StatusForbidden, game.ErrHandPrivate)
+		return
+	}
+
+	list := rm.handHistory()
+	idx := len(list) - 1
+	if s := q.Get("round"); s != "" {
+		round, err := strconv.Atoi(s)
+		if err != nil {
+			httpx.WriteJSON(w, http.StatusBadRequest, game.ErrInvalidPayload.WithInfo("round 应为整数"))
+			return
+		}
+		for idx >= 0 && list[idx].Dealt.RoundIndex != round {
+			idx--
+		}
+	}
+	if idx < 0 {
+		httpx.WriteJSON(w, http.StatusNotFound, game.ErrHandNotFound)
+		return
+	}
+	h := list[idx]
+	text, err := game.ExportHand(h)
+	if err != nil {
+		httpx.WriteJSON(w, http.StatusInternalServerError, err)
+		return
+	}
+	name := fmt.Sprintf("%s-round%d.txt", url.PathEscape(h.Dealt.RoomID), h.Dealt.RoundIndex)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	_, _ = w.Write([]byte(text))
+}
@@ -0,0 +1,235 @@
+package room
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"upgrade-lan/internal/bot"
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/transport"
+)
+
+// 房主命令：不进入 game.Reduce，由 room 处理（移座/踢人在大厅时以对方身份提交 room.sit / room.leave_seat）
+const (
+	CmdKick         = "room.kick"          // payload: {"uid": "..."}，移出房间并禁止再次加入（对局中由机器人代打到整局结束）
+	CmdMove         = "room.move"          // payload: {"uid": "...", "seat": n}，把玩家换到空座位（仅大厅）
+	CmdLockSeat     = "room.lock_seat"     // payload: {"seat": n, "locked": true}，锁定/解锁空座位
+	CmdTransferHost = "room.transfer_host" // payload: {"uid": "..."}
+)
+
+type KickPayload struct {
+	UID string `json:"uid"`
+}
+
+type MovePayload struct {
+	UID  string `json:"uid"`
+	Seat int    `json:"seat"`
+}
+
+type LockSeatPayload struct {
+	Seat   int  `json:"seat"`
+	Locked bool `json:"locked"`
+}
+
+// requireHost 还没有房主时（无人入座且创建者未知）不做限制
+func (r *Room) requireHost(c transport.Client) *game.AppError {
+	if r.host != "" && c.UID() != r.host {
+		return game.ErrRoomNotHost
+	}
+	return nil
+}
+
+// checkRoomRules 进入 Reduce 之前的房间级限制：开局/设置仅房主，锁定的座位不能入座
+func (r *Room) checkRoomRules(c transport.Client, typ game.ClientEventType, payload any) *game.AppError {
+	switch typ {
+	case game.EvStart, game.EvSettings:
+		return r.requireHost(c)
+	case game.EvSit:
+		if p, ok := payload.(game.SitPayload); ok && r.lockedSeats[p.Seat] {
+			return game.ErrRoomSeatLocked.WithInfof("%d号位已被房主锁定", p.Seat)
+		}
+	}
+	return nil
+}
+
+// ensureHost 房主不在座位上且已不在房间时，转交给座位号最小的真人玩家；还没有房主时由第一个入座的人担任
+func (r *Room) ensureHost() {
+	if r.host != "" {
+		if r.seatOf(r.host) >= 0 || r.connected(r.host) {
+			r.hostSeen = true
+			return
+		}
+		if !r.hostSeen {
+			return // 创建者还没进入房间
+		}
+	}
+	next := ""
+	for i := 0; i < 4; i++ {
+		uid := r.state.Seats[i].UID
+		if _, isBot := r.bots[uid]; uid != "" && !isBot {
+			next = uid
+			break
+		}
+	}
+	if next == r.host {
+		return
+	}
+	r.host = next
+	r.hostSeen = next != ""
+	r.roomRev++
+	if next != "" {
+		r.notice(game.NewNotice(game.NtHostChanged, game.NoticeParams{"uid": next}))
+	}
+}
+
+func (r *Room) connected(uid string) bool {
+	for c := range r.conns {
+		if c.UID() == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// decorateRoom 填写 view 中由 room 维护的信息：房主、锁定座位、观战者、悔棋表决
+func (r *Room) decorateRoom(view *game.ViewState) {
+	view.Host = r.host
+	for i := 0; i < 4; i++ {
+		view.Seats[i].Locked = r.lockedSeats[i]
+	}
+	view.Spectators = r.spectatorList()
+	r.decorateUndo(view)
+}
+
+func (r *Room) kick(c transport.Client, raw json.RawMessage) *game.AppError {
+	if err := r.requireHost(c); err != nil {
+		return err
+	}
+	var p KickPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return game.ErrBadJSON.WithInfo("踢人请求解析错误")
+	}
+	if p.UID == "" || p.UID == c.UID() {
+		return game.ErrInvalidPayload.WithInfo("不能移出自己")
+	}
+	if _, isBot := r.bots[p.UID]; isBot {
+		return game.ErrInvalidPayload.WithInfo("机器人请使用移除机器人")
+	}
+	seat := r.seatOf(p.UID)
+	if seat < 0 && !r.connected(p.UID) {
+		return game.ErrInvalidPayload.WithInfof("玩家%s不在房间内", p.UID)
+	}
+
+	r.access.kick(p.UID)
+	notice := game.NewNotice(game.NtHostKicked, game.NoticeParams{"uid": p.UID})
+	if seat >= 0 {
+		if r.state.Phase == game.PhaseLobby {
+			if err := r.applySystemAction(p.UID, game.EvLeave, struct{}{}); err != nil {
+				return err
+			}
+		} else {
+			// 游戏进行中：座位在小局之间无法空出（入座只能在大厅），由机器人以原 uid 代打到整局结束，
+			// 回到大厅时由 releaseKicked 让座；代打期间该座位不计入战绩
+			r.bots[p.UID] = bot.NewSimple()
+			notice = game.NewNotice(game.NtHostKickedBot, game.NoticeParams{"uid": p.UID, "seat": seat})
+		}
+	}
+	// Close 会先写完已排队的 ROOM_KICKED 再断开
+	for conn := range r.conns {
+		if conn.UID() == p.UID {
+			_ = conn.SendJSON(game.NewErrorMsg(game.ErrRoomKicked, "", ""))
+			_ = conn.Close()
+		}
+	}
+	r.notice(notice)
+	r.broadcastSnapshot()
+	r.scheduleBots()
+	return nil
+}
+
+// releaseKicked 回到大厅后，被移出的玩家的座位（对局中由机器人代打）让出
+func (r *Room) releaseKicked() {
+	for i := 0; i < 4; i++ {
+		uid := r.state.Seats[i].UID
+		if _, isBot := r.bots[uid]; !isBot || !r.access.isKicked(uid) {
+			continue
+		}
+		if err := r.applySystemAction(uid, game.EvLeave, struct{}{}); err != nil {
+			slog.Warn("被移出玩家让座失败", "room", r.id, "uid", uid, "err", err)
+			continue
+		}
+		delete(r.bots, uid)
+		r.dropAcks(uid)
+	}
+}
+
+func (r *Room) move(c transport.Client, raw json.RawMessage) *game.AppError {
+	if err := r.requireHost(c); err != nil {
+		return err
+	}
+	var p MovePayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return game.ErrBadJSON.WithInfo("换座请求解析错误")
+	}
+	if err := (game.SitPayload{Seat: p.Seat}).Validate(); err != nil {
+		return err
+	}
+	if r.state.Phase != game.PhaseLobby {
+		return game.ErrStateWrongPhase.WithInfo("只能在大厅换座")
+	}
+	if r.seatOf(p.UID) < 0 {
+		return game.ErrStateNotSeated.WithInfof("玩家%s尚未入座", p.UID)
+	}
+	if uid := r.state.Seats[p.Seat].UID; uid != "" {
+		return game.ErrStateSeatTaken.WithInfof("该座位已有玩家%s", uid)
+	}
+	// 换座同样走 room.sit -> Reduce（锁定检查也在其中）
+	return r.applySystemAction(p.UID, game.EvSit, game.SitPayload{Seat: p.Seat})
+}
+
+func (r *Room) lockSeat(c transport.Client, raw json.RawMessage) *game.AppError {
+	if err := r.requireHost(c); err != nil {
+		return err
+	}
+	var p LockSeatPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return game.ErrBadJSON.WithInfo("锁座请求解析错误")
+	}
+	if err := (game.SitPayload{Seat: p.Seat}).Validate(); err != nil {
+		return err
+	}
+	if p.Locked {
+		if uid := r.state.Seats[p.Seat].UID; uid != "" {
+			return game.ErrStateSeatTaken.WithInfof("只能锁定空座位，%d号位已有玩家%s", p.Seat, uid)
+		}
+	}
+	if r.lockedSeats[p.Seat] == p.Locked {
+		return nil
+	}
+	r.lockedSeats[p.Seat] = p.Locked
+	r.roomRev++
+	r.broadcastSnapshot()
+	return nil
+}
+
+func (r *Room) transferHost(c transport.Client, raw json.RawMessage) *game.AppError {
+	if err := r.requireHost(c); err != nil {
+		return err
+	}
+	var p KickPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return game.ErrBadJSON.WithInfo("转交房主请求解析错误")
+	}
+	if _, isBot := r.bots[p.UID]; isBot || p.UID == "" {
+		return game.ErrInvalidPayload.WithInfo("不能把房主转交给机器人")
+	}
+	if r.seatOf(p.UID) < 0 && !r.connected(p.UID) {
+		return game.ErrInvalidPayload.WithInfof("玩家%s不在房间内", p.UID)
+	}
+	r.host = p.UID
+	r.hostSeen = true
+	r.roomRev++
+	r.notice(game.NewNotice(game.NtHostChanged, game.NoticeParams{"uid": p.UID}))
+	r.broadcastSnapshot()
+	return nil
+}
@@ -0,0 +1,118 @@
+package room
+
+import (
+	"log/slog"
+	"time"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/game/rules"
+)
+
+// RoomInfo 房间目录中的一条（GET /rooms），由房间 goroutine 每次广播时发布，其他 goroutine 只读
+type RoomInfo struct {
+	ID          string        `json:"id"`
+	Phase       game.Phase    `json:"phase"`
+	Seats       [4]SeatInfo   `json:"seats"`
+	Levels      [2]rules.Rank `json:"levels"`     // 两队级牌
+	Locked      bool          `json:"locked"`     // 需要密码
+	InviteOnly  bool          `json:"inviteOnly"` // 仅限邀请
+	Spectators  int           `json:"spectators"`
+	Connections int           `json:"connections"`
+	CreatedAt   time.Time     `json:"createdAt"`
+}
+
+type SeatInfo struct {
+	UID    string `json:"uid,omitempty"`
+	Online bool   `json:"online"`
+	Bot    bool   `json:"bot,omitempty"`
+}
+
+// publishInfo 发布目录信息
+func (r *Room) publishInfo() {
+	info := &RoomInfo{
+		ID:          r.id,
+		Phase:       r.state.Phase,
+		Spectators:  len(r.spectators),
+		Connections: len(r.conns),
+		CreatedAt:   r.createdAt,
+	}
+	info.Locked, info.InviteOnly = r.access.locked()
+	for i := 0; i < 4; i++ {
+		s := r.state.Seats[i]
+		_, isBot := r.bots[s.UID]
+		info.Seats[i] = SeatInfo{UID: s.UID, Online: s.Online, Bot: isBot}
+	}
+	for t := 0; t < 2; t++ {
+		info.Levels[t] = r.state.Teams[t].LevelRank
+	}
+	r.info.Store(info)
+}
+
+// Info 当前目录信息（并发安全）
+func (r *Room) Info() RoomInfo {
+	return *r.info.Load()
+}
+
+// touchIdle 连接数变化后更新空闲起点：有连接时为 0
+func (r *Room) touchIdle() {
+	if len(r.conns) > 0 {
+		r.idleSince.Store(0)
+	} else if r.idleSince.Load() == 0 {
+		r.idleSince.Store(time.Now().UnixNano())
+	}
+}
+
+// idleFor 房间已无连接的时长；有连接时返回 0
+func (r *Room) idleFor(now time.Time) time.Duration {
+	since := r.idleSince.Load()
+	if since == 0 {
+		return 0
+	}
+	return now.Sub(time.Unix(0, since))
+}
+
+// stop 通知房间 goroutine 退出并等待清理完成；只能由 Manager 在把房间移出目录后调用一次
+func (r *Room) stop() {
+	close(r.quit)
+	<-r.done
+}
+
+// dormant 回收时是否保留落盘数据：对局进行中（含小局结算）的房间只是休眠，有人再连接时重新加载
+// 大厅与整局结束的房间没有需要保留的进度，回收时删除
+func (r *Room) dormant() bool {
+	return r.state.Phase != game.PhaseLobby && r.state.Phase != game.PhaseGameOver
+}
+
+// shutdown 房间 goroutine 退出前的清理：停计时器、拒绝回收前已排队的连接、关闭 replay、删除或保留落盘数据
+func (r *Room) shutdown() {
+	r.stopClockTimer()
+	for c := range r.conns {
+		_ = c.SendJSON(game.NewErrorMsg(game.ErrRoomNotFound, "", ""))
+		_ = c.Close()
+	}
+	for {
+		select {
+		case c := <-r.join:
+			_ = c.SendJSON(game.NewErrorMsg(game.ErrRoomNotFound, "", ""))
+			_ = c.Close()
+		case <-r.inbox:
+		case <-r.leave:
+		default:
+			if err := r.replay.Close(); err != nil {
+				slog.Warn("replay log 关闭失败", "room", r.id, "err", err)
+			}
+			if r.store != nil && r.dormant() {
+				r.persist()
+				slog.Info("房间已休眠", "room", r.id, "phase", r.state.Phase)
+				return
+			}
+			if r.store != nil {
+				if err := r.store.Delete(r.id); err != nil {
+					slog.Warn("房间数据删除失败", "room", r.id, "err", err)
+				}
+			}
+			slog.Info("房间已回收", "room", r.id)
+			return
+		}
+	}
+}
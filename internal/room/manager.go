@@ -1,55 +1,289 @@
 package room
 
 import (
+	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+	"unicode"
 
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/store"
 	"upgrade-lan/internal/transport"
 )
 
+// 短房间号：去掉易混淆的 0/O、1/I/L
+const (
+	roomCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+	roomCodeLen      = 5
+	maxRoomIDRunes   = 24
+)
+
+// Manager 房间目录
+// 所有向房间投递消息的操作都在读锁内进行；回收房间持写锁，保证回收后不会再有新消息进入该房间
 type Manager struct {
-	mu    sync.Mutex
+	mu    sync.RWMutex
+	opts  Options
 	rooms map[string]*Room
+	store *store.Store // 读取休眠房间；未开启落盘时为 nil
 }
 
-func NewManager() *Manager {
-	return &Manager{
+func NewManager(opts Options) *Manager {
+	m := &Manager{
+		opts:  opts,
 		rooms: make(map[string]*Room),
 	}
+	m.restoreRooms()
+	m.registerMetrics()
+	if opts.IdleTimeout > 0 {
+		go m.reapLoop()
+	}
+	return m
+}
+
+// restoreRooms 启动时从数据目录恢复所有房间
+func (m *Manager) restoreRooms() {
+	if m.opts.DataDir == "" {
+		return
+	}
+	s, err := store.New(m.opts.DataDir)
+	if err != nil {
+		slog.Warn("数据目录打开失败", "dir", m.opts.DataDir, "err", err)
+		return
+	}
+	saved, err := s.LoadAll()
+	if err != nil {
+		slog.Warn("读取房间数据失败", "dir", m.opts.DataDir, "err", err)
+		return
+	}
+	m.store = s
+	for roomID, b := range saved {
+		m.load(roomID, b)
+	}
 }
 
-func (m *Manager) getOrCreate(roomID string) *Room {
+// load 从落盘数据恢复一个房间并启动；调用方持有写锁（或处于启动阶段）
+func (m *Manager) load(roomID string, b []byte) bool {
+	r := NewRoom(roomID, m.opts)
+	if err := r.restore(b); err != nil {
+		slog.Warn("房间恢复失败", "room", roomID, "err", err)
+		return false
+	}
+	m.rooms[roomID] = r
+	go r.Run()
+	slog.Info("房间已恢复", "room", roomID, "phase", r.state.Phase, "version", r.state.Version)
+	return true
+}
+
+// stored 房间因空闲被回收但保留了落盘数据（休眠）；调用方持有锁
+func (m *Manager) stored(roomID string) ([]byte, bool) {
+	if m.store == nil {
+		return nil, false
+	}
+	b, err := m.store.Load(roomID)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("读取房间数据失败", "room", roomID, "err", err)
+		}
+		return nil, false
+	}
+	return b, true
+}
+
+// wake 有人连接休眠的房间时重新加载
+func (m *Manager) wake(roomID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if _, ok := m.rooms[roomID]; ok {
+		return
+	}
+	if b, ok := m.stored(roomID); ok {
+		m.load(roomID, b)
+	}
+}
+
+// CreateOptions 创建房间的参数
+type CreateOptions struct {
+	ID       string `json:"id"`       // 可空：为空时生成短房间号
+	Password string `json:"password"` // 可空：设置后加入需要密码
+	Invites  int    `json:"invites"`  // 生成多少个一次性邀请（0 表示不生成）
+	Host     string `json:"-"`        // 创建者 uid（由 Options.Authenticate 识别），为空时第一个入座的人成为房主
+}
+
+// Created 创建结果；Invites 只在创建时返回一次
+type Created struct {
+	RoomInfo
+	Invites []Invite `json:"invites,omitempty"`
+}
 
-	if r, ok := m.rooms[roomID]; ok {
-		return r
+// Create 显式创建房间；设置了密码或邀请的房间为私密房间
+func (m *Manager) Create(opts CreateOptions) (Created, *game.AppError) {
+	id := strings.TrimSpace(opts.ID)
+	if id != "" {
+		if err := validateRoomID(id); err != nil {
+			return Created{}, err
+		}
 	}
-	r := NewRoom(roomID)
-	m.rooms[roomID] = r
+	if len(opts.Password) > maxPasswordLen {
+		return Created{}, game.ErrInvalidPayload.WithInfof("房间密码最长%d个字符", maxPasswordLen)
+	}
+	if opts.Invites < 0 || opts.Invites > maxInvites {
+		return Created{}, game.ErrInvalidPayload.WithInfof("邀请数量需在0~%d之间", maxInvites)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id == "" {
+		for {
+			id = newRoomCode()
+			if !m.taken(id) {
+				break
+			}
+		}
+	} else if m.taken(id) {
+		return Created{}, game.ErrRoomExists.WithInfof("房间%s已存在", id)
+	}
+
+	r := NewRoom(id, m.opts)
+	invites := r.access.setup(opts.Password, opts.Invites, m.opts.InviteTTL)
+	if opts.Host != "" {
+		r.host = opts.Host
+		r.access.grant(opts.Host)
+	}
+	// Run 尚未启动，此时仍可在当前 goroutine 上访问；立即落盘，重启后未开局的房间（及邀请）也能恢复
+	r.publishInfo()
+	r.persist()
+	m.rooms[id] = r
 	go r.Run()
-	return r
+	slog.Info("房间已创建", "room", id, "password", opts.Password != "", "invites", len(invites))
+	return Created{RoomInfo: r.Info(), Invites: invites}, nil
+}
+
+// taken 房间号已被占用：房间在目录中，或正在休眠；调用方持有锁
+func (m *Manager) taken(id string) bool {
+	if _, ok := m.rooms[id]; ok {
+		return true
+	}
+	_, ok := m.stored(id)
+	return ok
+}
+
+func validateRoomID(id string) *game.AppError {
+	if len([]rune(id)) > maxRoomIDRunes {
+		return game.ErrRoomInvalidID.WithInfof("房间号最长%d个字符", maxRoomIDRunes)
+	}
+	for _, ch := range id {
+		if unicode.IsControl(ch) || unicode.IsSpace(ch) || ch == '/' {
+			return game.ErrRoomInvalidID.WithInfo("房间号不能包含空白、控制字符或 /")
+		}
+	}
+	return nil
+}
+
+func newRoomCode() string {
+	b := make([]byte, roomCodeLen)
+	max := big.NewInt(int64(len(roomCodeAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = roomCodeAlphabet[n.Int64()]
+	}
+	return string(b)
+}
+
+// List 房间目录，按房间号排序
+func (m *Manager) List() []RoomInfo {
+	m.mu.RLock()
+	out := make([]RoomInfo, 0, len(m.rooms))
+	for _, r := range m.rooms {
+		out = append(out, r.Info())
+	}
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// withRoom 在读锁内把消息投递给房间；房间不存在时返回 false
+func (m *Manager) withRoom(roomID string, fn func(r *Room)) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.rooms[roomID]
+	if !ok {
+		return false
+	}
+	fn(r)
+	return true
+}
+
+// reapLoop 定期回收无连接超过 IdleTimeout 的房间
+func (m *Manager) reapLoop() {
+	interval := m.opts.IdleTimeout / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		m.reapIdle(now)
+	}
+}
+
+func (m *Manager) reapIdle(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, r := range m.rooms {
+		// 仍有排队中的 join 说明有人刚进来，下一轮再看
+		if r.idleFor(now) < m.opts.IdleTimeout || len(r.join) > 0 {
+			continue
+		}
+		delete(m.rooms, id)
+		r.stop()
+	}
 }
 
 // —— 实现 ws.Router 接口（但这里不 import ws，因为接口在 ws 包里定义）
 // 为了不 import ws，我们直接让 ws.Router 依赖 transport.Client，
 // main.go 里传 rm 给 ws.ServeWS 即可（编译器会检查方法集匹配）。
 
-func (m *Manager) OnConnect(c transport.Client) {
-	r := m.getOrCreate(c.RoomID())
-	r.Join(c)
+// OnConnect 房间必须先通过 Create 创建（休眠的房间在此重新加载），私密房间在 Join 之前校验密码/邀请；返回错误时 ws 层拒绝该连接
+func (m *Manager) OnConnect(c transport.Client) error {
+	var denied *game.AppError
+	join := func(r *Room) {
+		if denied = r.access.admit(c.UID(), c.Credentials()); denied == nil {
+			r.Join(c)
+		}
+	}
+	found := m.withRoom(c.RoomID(), join)
+	if !found {
+		m.wake(c.RoomID())
+		found = m.withRoom(c.RoomID(), join)
+	}
+	if !found {
+		metricErrorsTotal.Inc(game.ErrRoomNotFound.Code)
+		return game.ErrRoomNotFound.WithInfof("房间%s不存在或已被回收", c.RoomID())
+	}
+	if denied != nil {
+		metricErrorsTotal.Inc(denied.Code)
+		return denied
+	}
+	return nil
 }
 
 func (m *Manager) OnDisconnect(c transport.Client) {
-	m.mu.Lock()
-	r := m.rooms[c.RoomID()]
-	m.mu.Unlock()
-	if r != nil {
-		r.Leave(c)
-	}
+	m.withRoom(c.RoomID(), func(r *Room) { r.Leave(c) })
 }
 
-func (m *Manager) OnMessage(c transport.Client, typ string, payload json.RawMessage) {
-	r := m.getOrCreate(c.RoomID())
-	r.Route(c, typ, payload)
+func (m *Manager) OnMessage(c transport.Client, typ string, reqID string, payload json.RawMessage) {
+	if !m.withRoom(c.RoomID(), func(r *Room) { r.Route(c, typ, reqID, payload) }) {
+		metricErrorsTotal.Inc(game.ErrRoomNotFound.Code)
+		_ = c.SendJSON(game.NewErrorMsg(game.ErrRoomNotFound.WithInfof("房间%s不存在或已被回收", c.RoomID()), "", ""))
+	}
 }
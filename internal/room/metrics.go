@@ -0,0 +1,39 @@
+package room
+
+import (
+	"upgrade-lan/internal/metrics"
+)
+
+// Reduce 通常在微秒级，桶从 10µs 到 50ms
+var reduceBuckets = []float64{0.00001, 0.00005, 0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.05}
+
+var (
+	metricReduced     = metrics.NewCounterVec("upgrade_events_reduced_total", "交给 Reduce 处理的客户端事件数（含机器人）", "type")
+	metricReduceTime  = metrics.NewHistogramVec("upgrade_reduce_duration_seconds", "单次 Reduce 耗时", "type", reduceBuckets)
+	metricErrorsTotal = metrics.NewCounterVec("upgrade_app_errors_total", "回给客户端的 AppError 数", "code")
+)
+
+// registerMetrics 抓取时遍历房间目录；房间数据取自并发安全的 Info 与 channel 长度
+func (m *Manager) registerMetrics() {
+	metrics.NewGaugeFunc("upgrade_rooms_active", "当前房间数", "", func() map[string]float64 {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		return map[string]float64{"": float64(len(m.rooms))}
+	})
+	metrics.NewGaugeFunc("upgrade_room_connections", "各房间的连接数（含观战）", "room", func() map[string]float64 {
+		return m.roomGauge(func(r *Room) int { return r.Info().Connections })
+	})
+	metrics.NewGaugeFunc("upgrade_room_inbox_depth", "各房间 inbox 中待处理的命令数", "room", func() map[string]float64 {
+		return m.roomGauge(func(r *Room) int { return len(r.inbox) })
+	})
+}
+
+func (m *Manager) roomGauge(fn func(r *Room) int) map[string]float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[string]float64, len(m.rooms))
+	for id, r := range m.rooms {
+		out[id] = float64(fn(r))
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package room
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"upgrade-lan/internal/bot"
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/replay"
+)
+
+// savedRoom 房间落盘内容
+type savedRoom struct {
+	ID     string          `json:"id"`
+	Bots   []string        `json:"bots"`  // 机器人 uid，恢复后重新接管
+	State  json.RawMessage `json:"state"` // game.MarshalFull
+	Access accessSnapshot  `json:"access"`
+
+	Host        string  `json:"host"`
+	HostSeen    bool    `json:"hostSeen"`
+	LockedSeats [4]bool `json:"lockedSeats"`
+}
+
+// persist Version、准入信息或房主/锁座变化时把完整 state 原子写入数据目录
+func (r *Room) persist() {
+	accessRev := r.access.revision()
+	if r.store == nil || (r.state.Version == r.savedVersion && accessRev == r.savedAccess && r.roomRev == r.savedRoom) {
+		return
+	}
+	full, err := game.MarshalFull(r.state)
+	if err != nil {
+		slog.Warn("房间状态序列化失败", "room", r.id, "err", err)
+		return
+	}
+	saved := savedRoom{
+		ID:          r.id,
+		State:       full,
+		Access:      r.access.snapshot(),
+		Host:        r.host,
+		HostSeen:    r.hostSeen,
+		LockedSeats: r.lockedSeats,
+	}
+	for uid := range r.bots {
+		saved.Bots = append(saved.Bots, uid)
+	}
+	b, err := json.Marshal(saved)
+	if err != nil {
+		slog.Warn("房间状态序列化失败", "room", r.id, "err", err)
+		return
+	}
+	if err := r.store.Save(r.id, b); err != nil {
+		slog.Warn("房间状态落盘失败", "room", r.id, "err", err)
+		return
+	}
+	r.savedVersion = r.state.Version
+	r.savedAccess = accessRev
+	r.savedRoom = r.roomRev
+}
+
+// restore 从落盘数据恢复：所有真人先标记离线，重连同一 uid 即回到原座位
+func (r *Room) restore(b []byte) error {
+	var saved savedRoom
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return err
+	}
+	st, err := game.UnmarshalFull(saved.State)
+	if err != nil {
+		return err
+	}
+	bots := make(map[string]bool, len(saved.Bots))
+	for _, uid := range saved.Bots {
+		bots[uid] = true
+	}
+	for i := 0; i < 4; i++ {
+		uid := st.Seats[i].UID
+		if uid == "" {
+			continue
+		}
+		if bots[uid] {
+			r.bots[uid] = bot.NewSimple()
+			continue
+		}
+		st.Seats[i].Online = false
+		st.Seats[i].Ready = false
+	}
+	r.access.restore(saved.Access)
+	r.host, r.hostSeen, r.lockedSeats = saved.Host, saved.HostSeen, saved.LockedSeats
+	r.state = st
+	r.savedVersion = st.Version
+	r.savedAccess = r.access.revision()
+	r.frames = nil
+	r.recordFrame()
+	for i := range r.clock.bank {
+		r.clock.bank[i] = r.turnTimers().Bank()
+	}
+	r.syncClock(st.Phase)
+	r.scheduleBots()
+	r.publishInfo()
+	r.appendReplay(replay.Entry{RoomID: r.id, Type: replay.TypeRestore, Payload: saved.State, Version: st.Version})
+	return nil
+}
@@ -2,68 +2,198 @@ package room
 
 import (
 	"encoding/json"
-	"fmt"
 	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"upgrade-lan/internal/bot"
 	"upgrade-lan/internal/game"
 	"upgrade-lan/internal/game/rules"
+	"upgrade-lan/internal/i18n"
+	"upgrade-lan/internal/replay"
+	"upgrade-lan/internal/stats"
+	"upgrade-lan/internal/store"
 	"upgrade-lan/internal/transport"
 )
 
 type incoming struct {
-	c   transport.Client
-	typ string
-	raw json.RawMessage
+	c     transport.Client
+	typ   string
+	reqID string
+	raw   json.RawMessage
+}
+
+// Options 房间启动选项
+type Options struct {
+	DealSeeds []rules.Seed    // 非空时按此固定序列发牌（复现用）；为空时使用 crypto/rand
+	ReplayDir string          // replay log 目录；为空时不记录
+	DataDir   string          // 房间状态落盘目录；为空时不落盘
+	Timers    game.TurnTimers // 新房间的默认操作时限，房主可在大厅通过 room.settings 修改
+
+	IdleTimeout time.Duration // 无连接超过该时长的房间被回收，0 表示不回收
+	InviteTTL   time.Duration // 私密房间邀请的有效期
+
+	// Authenticate 识别 POST /rooms 的创建者（带 ?token= 或 -open-lan 下的 ?uid=），创建者成为房主；可空
+	Authenticate func(r *http.Request) (string, error)
+
+	Stats *stats.Book // 玩家统计，可空（不统计）
+
+	SpectatorDelay int // 观战延迟（按 Version 计），0 表示实时
+	FollowDelay    int // 跟随座位观战的延迟（按 Version 计），0 表示不允许跟随
 }
 
 type Room struct {
-	id string
+	id     string
+	seeds  game.SeedSource
+	replay *replay.Writer // 可能为 nil（未开启或打开失败）
+
+	store        *store.Store // 可能为 nil（未开启或打开失败）
+	stats        *stats.Book  // 可能为 nil（不统计）
+	savedVersion int64
+	savedAccess  int // 已落盘的 access.rev
+
+	access *roomAccess // 私密房间准入（并发安全）
+
+	host        string  // 房主 uid（创建者或第一个入座的人）
+	hostSeen    bool    // 房主是否已进入过房间；创建者进房前不转交
+	lockedSeats [4]bool // 房主锁定的空座位
+	roomRev     int     // 房主/锁座变化时递增，用于判断是否需要落盘
+	savedRoom   int
 
 	join  chan transport.Client
 	leave chan transport.Client
 	inbox chan incoming
+	quit  chan struct{} // Manager 回收房间时关闭
+	done  chan struct{} // shutdown 完成后关闭
+
+	createdAt time.Time
+	idleSince atomic.Int64 // 无连接起点（UnixNano），有连接时为 0
+	info      atomic.Pointer[RoomInfo]
 
 	conns map[transport.Client]struct{}
+	acks  map[string]*ackWindow // uid -> 最近处理过的 reqId（幂等）
 	state game.GameState
+
+	bots       map[string]bot.Bot // uid -> 机器人
+	botSeq     int
+	botTick    chan struct{}
+	botPending bool
+
+	timers game.TurnTimers
+	clock  turnClock
+	clockC chan int
+
+	deltas        map[transport.Client]*deltaBase // 开启差量快照的连接
+	resendC       chan struct{}
+	resendPending bool
+
+	undoStack []undoEntry // 最近被接受的操作之前的 state（悔棋用）
+	undoVote  *undoVote
+
+	hand    *handLog                           // 进行中小局的牌谱，发牌前为 nil
+	handSeq int                                // 已归档的小局总数
+	hands   atomic.Pointer[[]game.HandHistory] // 已结束小局的牌谱（最近 maxHands 个）
+
+	chatLimits  map[string]*chatBucket // uid -> 发言令牌桶
+	chatHistory []game.ChatMsg
+	chatSeq     int64
+
+	spectators     map[transport.Client]*spectator
+	specDelay      int
+	followDelay    int
+	frames         []specFrame
+	pendingNotices []game.Notice   // 尚未归入 frame 的 notice
+	pendingEvents  []game.EventMsg // 尚未归入 frame 的领域事件
+	sysNotices     []game.Notice   // 系统动作附带的 notice，Reduce 成功后才下发
 }
 
-func NewRoom(id string) *Room {
-	st := game.GameState{
-		RoomID: id,
-		Phase:  game.PhaseLobby,
+func NewRoom(id string, opts Options) *Room {
+	st := game.NewGameState(id)
+
+	var seeds game.SeedSource = game.CryptoSeedSource{}
+	if len(opts.DealSeeds) > 0 {
+		seeds = game.NewFixedSeedSource(opts.DealSeeds...)
 	}
-	// 初始化座位所属队伍
-	for i := 0; i < 4; i++ {
-		st.Seats[i].Team = game.TeamOfSeat(i)
+
+	var rl *replay.Writer
+	if opts.ReplayDir != "" {
+		w, err := replay.Open(opts.ReplayDir, id)
+		if err != nil {
+			slog.Warn("replay log 打开失败", "room", id, "err", err)
+		} else {
+			rl = w
+			_ = rl.Append(replay.Entry{RoomID: id, Type: replay.TypeOpen, Version: st.Version})
+		}
 	}
-	// 初始化双方级牌 = 2
-	st.Teams[0].LevelRank = rules.R2
-	st.Teams[1].LevelRank = rules.R2
 
-	st.RoundIndex = 0
-	st.NextStarterSeat = 0 // 后续小局用（结算写回）
-	st.CallerSeat = -1     //
-	st.CallTurnSeat = -1   // 首局抢定主不需要turn
-	st.CallPassCount = 0
-	st.CallPassMask = 0
-	st.CallMode = game.CallModeRace // 首局抢定主
-	st.BottomOwnerSeat = -1
-	st.Trump.CallerSeat = -1
+	var ps *store.Store
+	if opts.DataDir != "" {
+		s, err := store.New(opts.DataDir)
+		if err != nil {
+			slog.Warn("数据目录打开失败", "room", id, "err", err)
+		} else {
+			ps = s
+		}
+	}
+
+	r := &Room{
+		id:           id,
+		store:        ps,
+		stats:        opts.Stats,
+		savedVersion: -1,
+		seeds:        seeds,
+		replay:       rl,
+		join:         make(chan transport.Client, 32),
+		leave:        make(chan transport.Client, 32),
+		inbox:        make(chan incoming, 128),
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+		access:       newRoomAccess(),
+		createdAt:    time.Now(),
+		conns:        make(map[transport.Client]struct{}),
+		acks:         make(map[string]*ackWindow),
+		state:        st,
+
+		bots:    make(map[string]bot.Bot),
+		botTick: make(chan struct{}, 1),
+
+		timers: opts.Timers,
+		clockC: make(chan int, 1),
+
+		deltas:     make(map[transport.Client]*deltaBase),
+		resendC:    make(chan struct{}, 1),
+		chatLimits: make(map[string]*chatBucket),
 
-	return &Room{
-		id:    id,
-		join:  make(chan transport.Client, 32),
-		leave: make(chan transport.Client, 32),
-		inbox: make(chan incoming, 128),
-		conns: make(map[transport.Client]struct{}),
-		state: st,
+		spectators:  make(map[transport.Client]*spectator),
+		specDelay:   opts.SpectatorDelay,
+		followDelay: opts.FollowDelay,
 	}
+	r.touchIdle()
+	r.publishInfo()
+	return r
 }
 
-func (r *Room) Join(c transport.Client)  { r.join <- c }
-func (r *Room) Leave(c transport.Client) { r.leave <- c }
+// Join/Leave/Route 由 Manager 在持有读锁时调用；房间已回收时消息直接丢弃
+func (r *Room) Join(c transport.Client) {
+	select {
+	case r.join <- c:
+	case <-r.quit:
+	}
+}
 
-func (r *Room) Route(c transport.Client, typ string, raw json.RawMessage) {
-	r.inbox <- incoming{c: c, typ: typ, raw: raw}
+func (r *Room) Leave(c transport.Client) {
+	select {
+	case r.leave <- c:
+	case <-r.quit:
+	}
+}
+
+func (r *Room) Route(c transport.Client, typ string, reqID string, raw json.RawMessage) {
+	select {
+	case r.inbox <- incoming{c: c, typ: typ, reqID: reqID, raw: raw}:
+	case <-r.quit:
+	}
 }
 
 func (r *Room) Run() {
@@ -71,66 +201,248 @@ func (r *Room) Run() {
 		select {
 		case c := <-r.join:
 			r.conns[c] = struct{}{}
-			// 若该 uid 已经坐下，标 online
-			for i := 0; i < 4; i++ {
-				if r.state.Seats[i].UID == c.UID() {
-					r.state.Seats[i].Online = true
-				}
-			}
+			r.touchIdle()
 			c.SendJSON(map[string]any{
 				"type": "hello",
 				"uid":  c.UID(),
 			})
+			r.sendChatHistory(c)
+			if c.Spectator() {
+				sp := &spectator{follow: -1}
+				r.spectators[c] = sp
+				r.pushSpectator(c, sp, true)
+				r.broadcastSnapshot() // 刷新观战者列表
+				continue
+			}
+			// 若该 uid 已经坐下，标 online
+			r.state = game.MarkOnline(r.state, c.UID())
+			r.appendReplay(replay.Entry{UID: c.UID(), Type: replay.TypeJoin, Version: r.state.Version})
 			r.broadcastSnapshot()
 
 		case c := <-r.leave:
 			delete(r.conns, c)
-			for i := 0; i < 4; i++ {
-				if r.state.Seats[i].UID == c.UID() {
-					r.state.Seats[i].Online = false
-					r.state.Seats[i].Ready = false
-				}
+			delete(r.deltas, c)
+			r.touchIdle()
+			if _, ok := r.spectators[c]; ok {
+				delete(r.spectators, c)
+				r.dropAcks(c.UID())
+				r.broadcastSnapshot()
+				_ = c.Close()
+				continue
+			}
+			r.dropAcks(c.UID())
+			// 顶号时新连接先入房、旧连接后离开：同 uid 还有连接就仍算在线
+			if !r.connected(c.UID()) {
+				r.state = game.MarkOffline(r.state, c.UID())
+				r.appendReplay(replay.Entry{UID: c.UID(), Type: replay.TypeLeave, Version: r.state.Version})
 			}
-			r.state.Version++
 			r.broadcastSnapshot()
 			_ = c.Close()
 
 		case msg := <-r.inbox:
-			r.handleEvent(msg.c, msg.typ, msg.raw)
+			r.handleEvent(msg.c, msg.typ, msg.reqID, msg.raw)
+
+		case <-r.botTick:
+			r.stepBots()
+
+		case gen := <-r.clockC:
+			r.onClock(gen)
+
+		case <-r.resendC:
+			r.resendStale()
+
+		case <-r.quit:
+			r.shutdown()
+			close(r.done)
+			return
 		}
 	}
 }
 
-func (r *Room) handleEvent(c transport.Client, typ string, raw json.RawMessage) {
+// handleEvent 处理一条客户端命令，并回 ack；失败时先回 error（带事件类型与 reqId）
+// 带 reqId 的命令按 uid 去重：重复的 reqId 直接返回缓存的 ack，不再 Reduce
+func (r *Room) handleEvent(c transport.Client, typ string, reqID string, raw json.RawMessage) {
+	var window *ackWindow
+	if reqID != "" {
+		window = r.acks[c.UID()]
+		if window == nil {
+			window = newAckWindow()
+			r.acks[c.UID()] = window
+		}
+		if ack, ok := window.get(reqID); ok {
+			_ = c.SendJSON(ack)
+			return
+		}
+	}
+
+	ack := game.AckMsg{Type: "ack", ReqID: reqID, OK: true}
+	if err := r.applyEvent(c, typ, raw); err != nil {
+		ack.OK = false
+		ack.Code = err.Code
+		metricErrorsTotal.Inc(err.Code)
+		slog.Warn(err.Error(), "room", r.id, "uid", c.UID(), "event", typ)
+		_ = c.SendJSON(game.NewErrorMsg(err, typ, reqID))
+	}
+	ack.Version = r.state.Version
+	if window != nil {
+		window.put(ack)
+	}
+	_ = c.SendJSON(ack)
+}
+
+func (r *Room) applyEvent(c transport.Client, typ string, raw json.RawMessage) *game.AppError {
+	switch typ {
+	case CmdSync:
+		return r.sync(c, raw)
+	case string(game.EvChat), string(game.EvEmote):
+		evType, payload, err := ParseClientEvent(typ, raw)
+		if err != nil {
+			return err
+		}
+		return r.chat(c, evType, payload)
+	}
+	if c.Spectator() {
+		if typ == CmdFollow {
+			return r.setFollow(c, raw)
+		}
+		return game.ErrStateSpectator.WithInfo("观战者只能选择跟随座位")
+	}
+
+	switch typ {
+	case CmdAddBot:
+		return r.addBot(c, raw)
+	case CmdRemoveBot:
+		return r.removeBot(c, raw)
+	case CmdKick:
+		return r.kick(c, raw)
+	case CmdMove:
+		return r.move(c, raw)
+	case CmdLockSeat:
+		return r.lockSeat(c, raw)
+	case CmdTransferHost:
+		return r.transferHost(c, raw)
+	case CmdProposeUndo:
+		return r.proposeUndo(c)
+	case CmdAcceptUndo:
+		return r.acceptUndo(c)
+	case CmdRejectUndo:
+		return r.rejectUndo(c)
+	}
+
 	evType, payload, err := ParseClientEvent(typ, raw)
 	if err != nil {
-		slog.Warn(err.Error())
-		fmt.Println(err.Error())
-		_ = c.SendJSON(game.ErrorMsg{Type: "error", Message: err.Error()})
-		return
+		return err
+	}
+	if err := r.checkRoomRules(c, evType, payload); err != nil {
+		return err
 	}
-	res, err := game.Reduce(r.state, c.UID(), evType, payload)
+	base := r.undoBase()
+	mark := r.handMark()
+	prev := r.state
+	seeds := &game.SeedRecorder{Src: r.seeds}
+	start := time.Now()
+	res, err := game.Reduce(r.state, seeds, c.UID(), evType, payload)
+	metricReduceTime.Observe(string(evType), time.Since(start))
+	metricReduced.Inc(string(evType))
 	if err != nil {
-		slog.Warn(err.Error())
-		_ = c.SendJSON(game.ErrorMsg{Type: "error", Message: err.Error()})
-		return
-	}
-	if res.Notice != "" {
-		slog.Info(res.Notice)
-		for cc := range r.conns {
-			_ = cc.SendJSON(game.NoticeMsg{Type: "notice", Message: res.Notice})
-		}
+		return err
+	}
+	r.appendReplay(replay.Entry{
+		UID:     c.UID(),
+		Type:    evType,
+		Payload: raw,
+		Version: res.State.Version,
+		Seed:    seeds.Used,
+	})
+	for _, n := range r.sysNotices {
+		r.notice(n)
+	}
+	r.sysNotices = nil
+	for _, n := range res.Notices {
+		r.notice(n)
+	}
+	for _, e := range res.Events {
+		r.event(res.State.Version, e)
 	}
 	if res.Changed {
+		prevPhase := r.state.Phase
+		r.chargeOvertime(c.UID())
 		r.state = res.State
+		delta := r.recordStats(&prev, c.UID(), evType)
+		r.recordHand(&prev, c.UID(), evType, payload)
+		r.recordUndo(base, mark, c.UID(), evType, delta)
+		r.syncClock(prevPhase)
 		r.broadcastSnapshot()
+		r.scheduleBots()
+		if prevPhase != game.PhaseLobby && r.state.Phase == game.PhaseLobby {
+			r.releaseKicked()
+		}
 	}
+	return nil
 }
 
+// notice 实时发给玩家；观战者随延迟的 frame 补发
+func (r *Room) notice(n game.Notice) {
+	slog.Info(i18n.Render(i18n.Default, string(n.Code), n.Params), "room", r.id)
+	for c := range r.conns {
+		if !c.Spectator() {
+			_ = c.SendJSON(noticeMsg(c, n))
+		}
+	}
+	r.pendingNotices = append(r.pendingNotices, n)
+}
+
+// event 与 notice 相同：实时发给玩家，观战者随延迟的 frame 补发
+func (r *Room) event(version int64, e game.DomainEvent) {
+	msg := game.NewEventMsg(version, e)
+	for c := range r.conns {
+		if !c.Spectator() {
+			_ = c.SendJSON(msg)
+		}
+	}
+	r.pendingEvents = append(r.pendingEvents, msg)
+}
+
+// noticeMsg 按连接选择的语言渲染
+func noticeMsg(c transport.Client, n game.Notice) game.NoticeMsg {
+	return game.NoticeMsg{
+		Type:    "notice",
+		Code:    n.Code,
+		Params:  n.Params,
+		Message: i18n.Render(i18n.Locale(c.Locale()), string(n.Code), n.Params),
+	}
+}
+
+// broadcastSnapshot 每次 state 变化后调用：落盘、记录观战 frame、下发快照
 func (r *Room) broadcastSnapshot() {
+	r.ensureHost()
+	r.persist()
+	r.publishInfo()
+	r.recordFrame()
 	for c := range r.conns {
+		if sp, ok := r.spectators[c]; ok {
+			r.pushSpectator(c, sp, false)
+			continue
+		}
 		view := game.MakeView(r.state, c.UID())
-		snap := game.Snapshot{Type: "snapshot", State: view}
-		_ = c.SendJSON(snap)
+		r.decorateRoom(&view)
+		r.decorateClock(&view)
+		r.sendView(c, view)
+	}
+}
+
+func (r *Room) appendReplay(e replay.Entry) {
+	if err := r.replay.Append(e); err != nil {
+		slog.Warn("replay log 写入失败", "room", r.id, "err", err)
+	}
+}
+
+// seatOf uid 所在座位，未入座返回 -1
+func (r *Room) seatOf(uid string) int {
+	for i := 0; i < 4; i++ {
+		if uid != "" && r.state.Seats[i].UID == uid {
+			return i
+		}
 	}
+	return -1
 }
@@ -23,7 +23,22 @@ func ParseClientEvent(typ string, raw json.RawMessage) (game.ClientEventType, an
 	case string(game.EvUnready):
 		return game.EvUnready, struct{}{}, nil
 	case string(game.EvStart):
-		return game.EvStart, struct{}{}, nil
+		var p game.StartPayload
+		if len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &p); err != nil {
+				return "", nil, game.ErrBadJSON.WithInfo("开局请求解析错误")
+			}
+		}
+		return game.EvStart, p, nil
+	case string(game.EvSettings):
+		var p game.SettingsPayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return "", nil, game.ErrBadJSON.WithInfo("房间设置请求解析错误")
+		}
+		if err := p.Validate(); err != nil {
+			return "", nil, err
+		}
+		return game.EvSettings, p, nil
 
 	case string(game.EvCallPass):
 		return game.EvCallPass, struct{}{}, nil
@@ -78,6 +93,27 @@ func ParseClientEvent(typ string, raw json.RawMessage) (game.ClientEventType, an
 		return game.EvPlayCards, p, nil
 	case string(game.EvStartNextRound):
 		return game.EvStartNextRound, struct{}{}, nil
+	case string(game.EvRematch):
+		return game.EvRematch, struct{}{}, nil
+
+	case string(game.EvChat):
+		var p game.ChatPayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return "", nil, game.ErrBadJSON.WithInfo("聊天请求解析错误")
+		}
+		if err := p.Validate(); err != nil {
+			return "", nil, err
+		}
+		return game.EvChat, p, nil
+	case string(game.EvEmote):
+		var p game.EmotePayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return "", nil, game.ErrBadJSON.WithInfo("表情请求解析错误")
+		}
+		if err := p.Validate(); err != nil {
+			return "", nil, err
+		}
+		return game.EvEmote, p, nil
 	default:
 		return "", nil, game.ErrUnknownEvent.WithInfof("非法事件 %s", typ)
 	}
@@ -0,0 +1,135 @@
+package room
+
+import (
+	"encoding/json"
+	"sort"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/transport"
+)
+
+// CmdFollow 观战者选择跟随某个座位（payload: {"seat": n}，-1 取消跟随）
+const CmdFollow = "room.follow"
+
+// specFrame 某个 Version 的完整 state 以及产生它的 notice/领域事件，用于延迟下发给观战者
+type specFrame struct {
+	state   game.GameState
+	notices []game.Notice
+	events  []game.EventMsg
+}
+
+// spectator 单个观战连接的进度
+type spectator struct {
+	follow      int   // 跟随的座位，-1 表示不跟随
+	lastVersion int64 // 已下发到的 frame 版本
+}
+
+type FollowPayload struct {
+	Seat int `json:"seat"`
+}
+
+// spectatorDelay 观战者的延迟（按 Version 计）：跟随座位时取两者较大值
+func (r *Room) spectatorDelay(sp *spectator) int64 {
+	d := int64(r.specDelay)
+	if sp.follow >= 0 && int64(r.followDelay) > d {
+		d = int64(r.followDelay)
+	}
+	return d
+}
+
+// recordFrame 记录当前 state（同 Version 覆盖），并裁剪所有观战者都不再需要的旧 frame
+func (r *Room) recordFrame() {
+	notices, events := r.pendingNotices, r.pendingEvents
+	r.pendingNotices, r.pendingEvents = nil, nil
+	if n := len(r.frames); n > 0 && r.frames[n-1].state.Version == r.state.Version {
+		r.frames[n-1].state = r.state.Clone()
+		r.frames[n-1].notices = append(r.frames[n-1].notices, notices...)
+		r.frames[n-1].events = append(r.frames[n-1].events, events...)
+	} else {
+		r.frames = append(r.frames, specFrame{state: r.state.Clone(), notices: notices, events: events})
+	}
+
+	maxDelay := int64(max(r.specDelay, r.followDelay))
+	keep := 0
+	for i, f := range r.frames {
+		if f.state.Version <= r.state.Version-maxDelay {
+			keep = i
+		}
+	}
+	r.frames = r.frames[keep:]
+}
+
+// frameIndexFor 延迟 delay 个版本后可见的最新 frame
+func (r *Room) frameIndexFor(delay int64) int {
+	idx := 0
+	for i, f := range r.frames {
+		if f.state.Version <= r.state.Version-delay {
+			idx = i
+		}
+	}
+	return idx
+}
+
+// pushSpectator 观战者的可见 frame 前进时，按顺序补发期间的 notice 与领域事件，再发快照
+func (r *Room) pushSpectator(c transport.Client, sp *spectator, force bool) {
+	if len(r.frames) == 0 {
+		return
+	}
+	idx := r.frameIndexFor(r.spectatorDelay(sp))
+	f := r.frames[idx]
+	if !force && f.state.Version <= sp.lastVersion {
+		return
+	}
+	if !force {
+		for _, fr := range r.frames[:idx+1] {
+			if fr.state.Version <= sp.lastVersion {
+				continue
+			}
+			for _, n := range fr.notices {
+				_ = c.SendJSON(noticeMsg(c, n))
+			}
+			for _, e := range fr.events {
+				_ = c.SendJSON(e)
+			}
+		}
+	}
+	// 延迟变大时画面会回退，但已补发过的 notice 不重复发送
+	sp.lastVersion = max(sp.lastVersion, f.state.Version)
+	view := game.MakeSpectatorView(f.state, sp.follow)
+	r.decorateRoom(&view)
+	r.sendView(c, view)
+}
+
+func (r *Room) spectatorList() []string {
+	seen := make(map[string]struct{}, len(r.spectators))
+	out := make([]string, 0, len(r.spectators))
+	for c := range r.spectators {
+		if _, ok := seen[c.UID()]; ok {
+			continue
+		}
+		seen[c.UID()] = struct{}{}
+		out = append(out, c.UID())
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (r *Room) setFollow(c transport.Client, raw json.RawMessage) *game.AppError {
+	sp, ok := r.spectators[c]
+	if !ok {
+		return game.ErrInvalidPayload.WithInfo("只有观战者可以跟随座位")
+	}
+	var p FollowPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return game.ErrBadJSON.WithInfo("跟随请求解析错误")
+	}
+	if p.Seat < -1 || p.Seat > 3 {
+		return game.ErrSeatRange
+	}
+	if p.Seat >= 0 && r.followDelay <= 0 {
+		return game.ErrInvalidPayload.WithInfo("本房间未开启跟随观战")
+	}
+	sp.follow = p.Seat
+	r.pushSpectator(c, sp, true)
+	return nil
+}
@@ -0,0 +1,21 @@
+package room
+
+import (
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/stats"
+)
+
+// recordStats 把本次事件的统计增量写入 Book（机器人不计），返回写入的增量供悔棋时扣除
+func (r *Room) recordStats(prev *game.GameState, uid string, typ game.ClientEventType) map[string]*stats.PlayerStats {
+	if r.stats == nil {
+		return nil
+	}
+	delta := stats.Diff(prev, &r.state, uid, typ)
+	for u := range delta {
+		if _, isBot := r.bots[u]; isBot {
+			delete(delta, u)
+		}
+	}
+	r.stats.Add(delta)
+	return delta
+}
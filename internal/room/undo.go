@@ -0,0 +1,175 @@
+package room
+
+import (
+	"log/slog"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/replay"
+	"upgrade-lan/internal/stats"
+	"upgrade-lan/internal/transport"
+)
+
+// 悔棋：撤回最近一次被接受的游戏操作，需其余在座真人全部同意（机器人视为同意）
+// 历史 state 只保存在内存中，服务重启后清空
+const (
+	CmdProposeUndo = "game.propose_undo"
+	CmdAcceptUndo  = "game.accept_undo"
+	CmdRejectUndo  = "game.reject_undo"
+)
+
+const maxUndo = 8 // 最多可连续撤回的操作数
+
+// undoEntry 某次操作之前的 state、牌谱进度，以及该操作计入的统计（撤回时一并扣除）
+type undoEntry struct {
+	state game.GameState
+	hand  handMark
+	uid   string
+	typ   game.ClientEventType
+	stats map[string]*stats.PlayerStats
+}
+
+// undoVote 进行中的悔棋表决；任何 state 变化都会使其作废
+type undoVote struct {
+	proposer string
+	accepted map[string]bool
+}
+
+// undoBase 操作前的 state 副本（Reduce 会原地改写手牌，必须在 Reduce 之前 Clone）；大厅阶段或已关闭悔棋时返回 nil
+func (r *Room) undoBase() *game.GameState {
+	if r.state.Phase == game.PhaseLobby || r.state.DisableUndo {
+		return nil
+	}
+	st := r.state.Clone()
+	return &st
+}
+
+// recordUndo state 被某次操作改变后调用：压入操作前的 state，并使进行中的表决作废
+func (r *Room) recordUndo(base *game.GameState, mark handMark, uid string, typ game.ClientEventType, delta map[string]*stats.PlayerStats) {
+	if r.undoVote != nil {
+		r.undoVote = nil
+		r.notice(game.NewNotice(game.NtUndoStale, nil))
+	}
+	if r.state.Phase == game.PhaseLobby {
+		r.undoStack = nil // 再来一局后不再撤回上一整局
+		return
+	}
+	if base == nil {
+		return
+	}
+	r.undoStack = append(r.undoStack, undoEntry{state: *base, hand: mark, uid: uid, typ: typ, stats: delta})
+	if len(r.undoStack) > maxUndo {
+		r.undoStack = r.undoStack[len(r.undoStack)-maxUndo:]
+	}
+}
+
+func (r *Room) proposeUndo(c transport.Client) *game.AppError {
+	if r.seatOf(c.UID()) < 0 {
+		return game.ErrStateNotSeated
+	}
+	if r.state.DisableUndo {
+		return game.ErrRoomUndoDisabled
+	}
+	if len(r.undoStack) == 0 {
+		return game.ErrRoomUndoEmpty
+	}
+	if r.undoVote != nil {
+		return game.ErrDuplicateOps.WithInfof("玩家%s的悔棋请求正在表决", r.undoVote.proposer)
+	}
+	r.undoVote = &undoVote{proposer: c.UID(), accepted: map[string]bool{c.UID(): true}}
+	last := r.undoStack[len(r.undoStack)-1]
+	r.notice(game.NewNotice(game.NtUndoRequest, game.NoticeParams{"uid": c.UID(), "target": last.uid, "action": last.typ}))
+	r.tallyUndo()
+	return nil
+}
+
+func (r *Room) acceptUndo(c transport.Client) *game.AppError {
+	if r.undoVote == nil {
+		return game.ErrRoomUndoNoVote
+	}
+	if r.seatOf(c.UID()) < 0 {
+		return game.ErrStateNotSeated
+	}
+	r.undoVote.accepted[c.UID()] = true
+	r.tallyUndo()
+	return nil
+}
+
+func (r *Room) rejectUndo(c transport.Client) *game.AppError {
+	if r.undoVote == nil {
+		return game.ErrRoomUndoNoVote
+	}
+	if r.seatOf(c.UID()) < 0 {
+		return game.ErrStateNotSeated
+	}
+	r.undoVote = nil
+	r.notice(game.NewNotice(game.NtUndoRejected, game.NoticeParams{"uid": c.UID()}))
+	r.broadcastSnapshot()
+	return nil
+}
+
+// tallyUndo 在座真人全部同意则撤回，否则刷新表决进度
+func (r *Room) tallyUndo() {
+	for i := 0; i < 4; i++ {
+		uid := r.state.Seats[i].UID
+		if _, isBot := r.bots[uid]; uid == "" || isBot {
+			continue
+		}
+		if !r.undoVote.accepted[uid] {
+			r.broadcastSnapshot()
+			return
+		}
+	}
+	r.undoVote = nil
+	r.rollback()
+}
+
+// rollback 恢复最近一次操作之前的 state；Version 继续递增，保证差量快照和观战 frame 单调
+func (r *Room) rollback() {
+	last := r.undoStack[len(r.undoStack)-1]
+	for i := 0; i < 4; i++ {
+		if last.state.Seats[i].UID != r.state.Seats[i].UID {
+			r.undoStack = nil
+			r.notice(game.NewNotice(game.NtUndoSeatChanged, nil))
+			r.broadcastSnapshot()
+			return
+		}
+	}
+	r.undoStack = r.undoStack[:len(r.undoStack)-1]
+	r.stats.Sub(last.stats)
+	r.rewindHand(last.hand)
+
+	st := last.state
+	st.Version = r.state.Version + 1
+	for i := 0; i < 4; i++ {
+		st.Seats[i].Online = r.state.Seats[i].Online
+	}
+	prevPhase := r.state.Phase
+	r.state = st
+
+	if b, err := game.MarshalFull(st); err != nil {
+		slog.Warn("悔棋 state 序列化失败", "room", r.id, "err", err)
+	} else {
+		r.appendReplay(replay.Entry{Type: replay.TypeUndo, Payload: b, Version: st.Version})
+	}
+	r.clock.key = "" // 撤回后重新计时
+	r.syncClock(prevPhase)
+	r.notice(game.NewNotice(game.NtUndoDone, game.NoticeParams{"target": last.uid, "action": last.typ}))
+	r.broadcastSnapshot()
+	r.scheduleBots()
+}
+
+// decorateUndo 填写 view 中的悔棋表决进度
+func (r *Room) decorateUndo(view *game.ViewState) {
+	v := r.undoVote
+	if v == nil {
+		return
+	}
+	last := r.undoStack[len(r.undoStack)-1]
+	uv := &game.UndoVoteView{Proposer: v.proposer, TargetUID: last.uid, Action: last.typ}
+	for i := 0; i < 4; i++ {
+		if uid := r.state.Seats[i].UID; v.accepted[uid] {
+			uv.Accepted = append(uv.Accepted, uid)
+		}
+	}
+	view.UndoVote = uv
+}
@@ -0,0 +1,176 @@
+package room
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"upgrade-lan/internal/bot"
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/game/rules"
+)
+
+// startedRoom 四名玩家入座并准备，固定种子发牌后进入定主阶段；bots 中的座位换成机器人
+func startedRoom(t *testing.T, bots ...int) (*Room, []*fakeClient) {
+	t.Helper()
+	r := NewRoom("t", Options{DealSeeds: []rules.Seed{rules.SeedFromInt(7)}})
+	cs := make([]*fakeClient, 4)
+	for i := range cs {
+		cs[i] = &fakeClient{uid: fmt.Sprintf("p%d", i)}
+		r.conns[cs[i]] = struct{}{}
+		sit(t, r, cs[i].uid, i)
+	}
+	for _, i := range bots {
+		r.bots[cs[i].uid] = bot.NewSimple()
+	}
+	for _, c := range cs {
+		if err := r.applySystemAction(c.uid, game.EvReady, struct{}{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if r.state.Phase != game.PhaseCallTrump {
+		t.Fatalf("准备后处于 %s 阶段，期望定主", r.state.Phase)
+	}
+	return r, cs
+}
+
+// step 让第一个等待中的座位执行默认动作
+func step(t *testing.T, r *Room) {
+	t.Helper()
+	seat := game.PendingSeats(r.state)[0]
+	typ, payload, ok := game.DefaultAction(r.state, seat)
+	if !ok {
+		t.Fatalf("%d号位没有默认动作", seat)
+	}
+	if err := r.applySystemAction(r.state.Seats[seat].UID, typ, payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fullState 完整 state（含手牌、底牌）的编码，忽略只增不减的 Version
+func fullState(t *testing.T, st game.GameState) []byte {
+	t.Helper()
+	st.Version = 0
+	b, err := game.MarshalFull(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestUndoRollback(t *testing.T) {
+	type vote struct {
+		seat int
+		cmd  string // CmdProposeUndo / CmdAcceptUndo / CmdRejectUndo
+	}
+	propose := func(seat int) vote { return vote{seat, CmdProposeUndo} }
+	accept := func(seat int) vote { return vote{seat, CmdAcceptUndo} }
+	reject := func(seat int) vote { return vote{seat, CmdRejectUndo} }
+
+	cases := []struct {
+		name   string
+		bots   []int
+		steps  int
+		rounds [][]vote // 每轮一次表决
+		undone int      // 期望撤回的操作数
+		voting bool     // 期望表决仍在进行
+	}{
+		{"全员同意撤回一步", nil, 1, [][]vote{{propose(0), accept(1), accept(2), accept(3)}}, 1, false},
+		{"连续撤回两步", nil, 3, [][]vote{
+			{propose(1), accept(0), accept(2), accept(3)},
+			{propose(2), accept(0), accept(1), accept(3)},
+		}, 2, false},
+		{"有人未表态不撤回", nil, 1, [][]vote{{propose(0), accept(1), accept(2)}}, 0, true},
+		{"有人拒绝不撤回", nil, 2, [][]vote{{propose(0), accept(1), reject(2), accept(3)}}, 0, false},
+		{"机器人视为同意", []int{2, 3}, 2, [][]vote{{propose(0), accept(1)}}, 1, false},
+		{"只剩机器人时提议即撤回", []int{1, 2, 3}, 1, [][]vote{{propose(0)}}, 1, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, cs := startedRoom(t, tc.bots...)
+			history := []game.GameState{r.state.Clone()}
+			marks := []handMark{r.handMark()}
+			for i := 0; i < tc.steps; i++ {
+				step(t, r)
+				history = append(history, r.state.Clone())
+				marks = append(marks, r.handMark())
+			}
+			version := r.state.Version
+
+			for _, round := range tc.rounds {
+				for _, v := range round {
+					c := cs[v.seat]
+					var err *game.AppError
+					switch v.cmd {
+					case CmdProposeUndo:
+						err = r.proposeUndo(c)
+					case CmdAcceptUndo:
+						err = r.acceptUndo(c)
+					case CmdRejectUndo:
+						err = r.rejectUndo(c)
+					}
+					// 已撤回或已被拒绝后的多余表态返回“没有进行中的表决”
+					if err != nil && err.Code != game.ErrRoomUndoNoVote.Code {
+						t.Fatalf("%s %s: %v", c.uid, v.cmd, err)
+					}
+				}
+			}
+
+			want := history[tc.steps-tc.undone]
+			if !bytes.Equal(fullState(t, r.state), fullState(t, want)) {
+				t.Fatalf("撤回 %d 步后的 state 与操作前不一致", tc.undone)
+			}
+			if tc.undone > 0 && r.state.Version <= version {
+				t.Fatalf("撤回后 Version %d 没有递增（撤回前 %d）", r.state.Version, version)
+			}
+			if got := r.handMark(); got != marks[tc.steps-tc.undone] {
+				t.Fatalf("牌谱进度 %+v，期望 %+v", got, marks[tc.steps-tc.undone])
+			}
+			if len(r.undoStack) != tc.steps-tc.undone {
+				t.Fatalf("悔棋栈 %d 条，期望 %d 条", len(r.undoStack), tc.steps-tc.undone)
+			}
+			if (r.undoVote != nil) != tc.voting {
+				t.Fatalf("表决 %v，期望进行中 = %v", r.undoVote, tc.voting)
+			}
+		})
+	}
+}
+
+// 表决期间有新的操作：表决作废，之后的同意不会撤回
+func TestUndoVoteStaleAfterAction(t *testing.T) {
+	r, cs := startedRoom(t)
+	step(t, r)
+	if err := r.proposeUndo(cs[0]); err != nil {
+		t.Fatal(err)
+	}
+	step(t, r)
+	if r.undoVote != nil {
+		t.Fatal("新操作之后表决仍在进行")
+	}
+	after := fullState(t, r.state)
+	if err := r.acceptUndo(cs[1]); err == nil || err.Code != game.ErrRoomUndoNoVote.Code {
+		t.Fatalf("acceptUndo = %v，期望 %s", err, game.ErrRoomUndoNoVote.Code)
+	}
+	if !bytes.Equal(fullState(t, r.state), after) {
+		t.Fatal("作废的表决仍然撤回了操作")
+	}
+}
+
+// 悔棋栈只保留最近 maxUndo 次操作
+func TestUndoStackLimit(t *testing.T) {
+	r, cs := startedRoom(t, 1, 2, 3)
+	for i := 0; i < maxUndo+3 && len(game.PendingSeats(r.state)) > 0; i++ {
+		step(t, r)
+	}
+	if len(r.undoStack) != maxUndo {
+		t.Fatalf("悔棋栈 %d 条，期望 %d", len(r.undoStack), maxUndo)
+	}
+	for len(r.undoStack) > 0 {
+		if err := r.proposeUndo(cs[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.proposeUndo(cs[0]); err == nil || err.Code != game.ErrRoomUndoEmpty.Code {
+		t.Fatalf("栈空后 proposeUndo = %v，期望 %s", err, game.ErrRoomUndoEmpty.Code)
+	}
+}
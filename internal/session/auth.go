@@ -0,0 +1,125 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/httpx"
+)
+
+const (
+	maxNameRunes = 16
+	uidSuffixLen = 4
+	uidAlphabet  = "23456789abcdefghjkmnpqrstuvwxyz"
+)
+
+// Auth ws 握手鉴权
+// - 默认只接受 ?token=（POST /session 签发）
+// - OpenLAN 为 true 时，没有 token 的连接仍可用 ?uid= 直接指定身份（旧行为，仅适合可信局域网）
+type Auth struct {
+	Signer  *Signer
+	OpenLAN bool
+}
+
+// Authenticate 实现 ws.Authenticator
+func (a *Auth) Authenticate(r *http.Request) (string, error) {
+	if token := r.URL.Query().Get("token"); token != "" {
+		c, err := a.Signer.Verify(token)
+		if err != nil {
+			return "", err
+		}
+		return c.UID, nil
+	}
+	if !a.OpenLAN {
+		return "", game.ErrSessionRequired
+	}
+	uid := normalizeName(r.URL.Query().Get("uid"), 24)
+	if uid == "" {
+		uid = time.Now().Format("150405")
+	}
+	return uid, nil
+}
+
+type sessionReq struct {
+	Name  string `json:"name"`
+	Token string `json:"token"` // 可选：已有 token 时续期并沿用原 uid
+}
+
+type sessionResp struct {
+	Token     string `json:"token"`
+	UID       string `json:"uid"`
+	Name      string `json:"name"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// ServeSession HTTP POST /session
+// uid 由服务端生成（昵称#随机后缀），客户端无法指定别人的 uid；带上旧 token 则续期并保留 uid
+func (a *Auth) ServeSession(w http.ResponseWriter, r *http.Request) {
+	if httpx.AllowCORS(w, r, "POST") {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req sessionReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpx.WriteJSON(w, http.StatusBadRequest, game.ErrBadJSON.WithInfo("会话请求解析错误"))
+			return
+		}
+	}
+	name := normalizeName(req.Name, maxNameRunes)
+
+	uid := ""
+	if req.Token != "" {
+		if c, err := a.Signer.Verify(req.Token); err == nil {
+			uid = c.UID
+			if name == "" {
+				name = c.Name
+			}
+		}
+	}
+	if name == "" {
+		name = "玩家"
+	}
+	if uid == "" {
+		uid = name + "#" + randomSuffix()
+	}
+
+	token, c := a.Signer.Issue(uid, name)
+	httpx.WriteJSON(w, http.StatusOK, sessionResp{Token: token, UID: c.UID, Name: c.Name, ExpiresAt: c.ExpiresAt})
+}
+
+// normalizeName 去掉首尾空白和控制字符，并限制长度；'#' 保留给 uid 后缀
+func normalizeName(raw string, maxRunes int) string {
+	s := strings.TrimSpace(raw)
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '#' {
+			return -1
+		}
+		return r
+	}, s)
+	rs := []rune(s)
+	if len(rs) > maxRunes {
+		s = string(rs[:maxRunes])
+	}
+	return s
+}
+
+func randomSuffix() string {
+	b := make([]byte, uidSuffixLen)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		b[i] = uidAlphabet[int(b[i])%len(uidAlphabet)]
+	}
+	return string(b)
+}
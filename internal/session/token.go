@@ -0,0 +1,96 @@
+// Package session 会话：签发绑定 uid 与昵称的 HMAC token，ws 握手时校验
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"upgrade-lan/internal/game"
+)
+
+// Claims token 中携带的信息
+type Claims struct {
+	UID       string `json:"uid"`
+	Name      string `json:"name"`
+	IssuedAt  int64  `json:"iat"` // unix 秒
+	ExpiresAt int64  `json:"exp"` // unix 秒
+}
+
+// Signer 签发与校验 token：base64url(claims JSON) + "." + base64url(HMAC-SHA256)
+type Signer struct {
+	key []byte
+	ttl time.Duration
+}
+
+func NewSigner(key []byte, ttl time.Duration) *Signer {
+	return &Signer{key: key, ttl: ttl}
+}
+
+func (s *Signer) Issue(uid, name string) (string, Claims) {
+	now := time.Now()
+	c := Claims{UID: uid, Name: name, IssuedAt: now.Unix(), ExpiresAt: now.Add(s.ttl).Unix()}
+	body, _ := json.Marshal(c)
+	enc := base64.RawURLEncoding.EncodeToString(body)
+	return enc + "." + s.sign(enc), c
+}
+
+func (s *Signer) Verify(token string) (Claims, *game.AppError) {
+	enc, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.sign(enc))) {
+		return Claims{}, game.ErrSessionInvalid
+	}
+	body, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil {
+		return Claims{}, game.ErrSessionInvalid
+	}
+	var c Claims
+	if err := json.Unmarshal(body, &c); err != nil || c.UID == "" {
+		return Claims{}, game.ErrSessionInvalid
+	}
+	if time.Now().Unix() >= c.ExpiresAt {
+		return Claims{}, game.ErrSessionExpired
+	}
+	return c, nil
+}
+
+func (s *Signer) sign(enc string) string {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(enc))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// LoadOrCreateKey 读取签名密钥；文件不存在时生成随机密钥并写入（权限 0600），
+// 这样服务重启后旧 token 仍然有效，恢复的房间里玩家能回到原座位
+func LoadOrCreateKey(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err == nil && len(b) > 0 {
+		return b, nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	key := RandomKey()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, key, 0o600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func RandomKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"upgrade-lan/internal/game"
+)
+
+// forge 用 s 的密钥给任意 claims 签名（模拟过期或缺字段的 token）
+func forge(s *Signer, c Claims) string {
+	body, _ := json.Marshal(c)
+	return forgeRaw(s, string(body))
+}
+
+func forgeRaw(s *Signer, body string) string {
+	enc := base64.RawURLEncoding.EncodeToString([]byte(body))
+	return enc + "." + s.sign(enc)
+}
+
+func TestSignVerify(t *testing.T) {
+	s := NewSigner([]byte("key-a"), time.Hour)
+	other := NewSigner([]byte("key-b"), time.Hour)
+	good, claims := s.Issue("u1", "张三")
+	enc, sig, _ := strings.Cut(good, ".")
+	flipped := sig[:len(sig)-1] + "A"
+	if strings.HasSuffix(sig, "A") {
+		flipped = sig[:len(sig)-1] + "B"
+	}
+	now := time.Now().Unix()
+
+	cases := []struct {
+		name  string
+		token string
+		want  *game.AppError // nil 表示通过
+	}{
+		{"正常签发", good, nil},
+		{"其他密钥签发", func() string { tok, _ := other.Issue("u1", "张三"); return tok }(), game.ErrSessionInvalid},
+		{"篡改内容", base64.RawURLEncoding.EncodeToString([]byte(`{"uid":"u2","name":"x","exp":9999999999}`)) + "." + sig, game.ErrSessionInvalid},
+		{"篡改签名", enc + "." + flipped, game.ErrSessionInvalid},
+		{"缺少签名", enc, game.ErrSessionInvalid},
+		{"空 token", "", game.ErrSessionInvalid},
+		{"内容不是 base64", "!!!." + s.sign("!!!"), game.ErrSessionInvalid},
+		{"内容不是 JSON", forgeRaw(s, "not json"), game.ErrSessionInvalid},
+		{"缺少 uid", forge(s, Claims{Name: "x", ExpiresAt: now + 60}), game.ErrSessionInvalid},
+		{"已过期", forge(s, Claims{UID: "u1", IssuedAt: now - 120, ExpiresAt: now - 60}), game.ErrSessionExpired},
+		{"恰好到期", forge(s, Claims{UID: "u1", ExpiresAt: now}), game.ErrSessionExpired},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.Verify(tc.token)
+			if tc.want == nil {
+				if err != nil || got != claims {
+					t.Fatalf("Verify = %+v, %v；期望 %+v", got, err, claims)
+				}
+				return
+			}
+			if err == nil || err.Code != tc.want.Code {
+				t.Fatalf("Verify = %+v, %v；期望 %s", got, err, tc.want.Code)
+			}
+		})
+	}
+}
+
+func TestIssueExpiry(t *testing.T) {
+	s := NewSigner([]byte("k"), 2*time.Hour)
+	_, c := s.Issue("u1", "")
+	if c.ExpiresAt-c.IssuedAt != int64((2 * time.Hour).Seconds()) {
+		t.Fatalf("有效期 %d 秒，期望 7200", c.ExpiresAt-c.IssuedAt)
+	}
+}
+
+// 密钥落盘后重新加载得到同一把密钥，旧 token 在重启后仍然有效
+func TestLoadOrCreateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "session.key")
+	k1, err := LoadOrCreateKey(path)
+	if err != nil || len(k1) != 32 {
+		t.Fatalf("生成密钥 = %d 字节, %v", len(k1), err)
+	}
+	k2, err := LoadOrCreateKey(path)
+	if err != nil || !slices.Equal(k1, k2) {
+		t.Fatalf("重新加载的密钥与首次生成的不同: %v", err)
+	}
+	tok, _ := NewSigner(k1, time.Hour).Issue("u1", "")
+	if _, err := NewSigner(k2, time.Hour).Verify(tok); err != nil {
+		t.Fatalf("重启后旧 token 校验失败: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/game/rules"
+)
+
+// Diff 从一次被接受的事件前后的 state 推导各 uid 的统计增量（纯函数，没有增量时返回 nil）
+// - 先手出牌后 Trick.Throw 非空：一次甩牌尝试
+// - TrickIndex 前进：一墩结束，赢家计墩数，打家赢墩计吃分
+// - 进入小局结算/整局结束：抠底、小局结果、升级、整局胜负
+func Diff(prev, next *game.GameState, uid string, typ game.ClientEventType) map[string]*PlayerStats {
+	out := make(map[string]*PlayerStats)
+	get := func(seat int) *PlayerStats {
+		u := next.Seats[seat].UID
+		if u == "" {
+			return nil
+		}
+		if out[u] == nil {
+			out[u] = &PlayerStats{UID: u}
+		}
+		return out[u]
+	}
+
+	if typ == game.EvPlayCards && prev.Phase == game.PhasePlayTrick {
+		if leading(prev) && next.Trick.Throw != nil {
+			if p := get(prev.Trick.LeaderSeat); p != nil {
+				p.Throws++
+				if next.Trick.Throw.ThrowOK {
+					p.ThrowsOK++
+				}
+			}
+		}
+		if next.TrickIndex > prev.TrickIndex {
+			if w := next.Trick.WinnerSeat; w >= 0 && w < 4 {
+				if p := get(w); p != nil {
+					p.TricksWon++
+					if !sameTeam(next, w, next.CallerSeat) {
+						p.PointsCaptured += trickPoints(next.Trick.LastPlays)
+					}
+				}
+			}
+		}
+	}
+
+	settled := next.Phase == game.PhaseRoundSettle || next.Phase == game.PhaseGameOver
+	if settled && prev.Phase == game.PhasePlayTrick {
+		if w := next.Trick.WinnerSeat; next.BottomAward > 0 && w >= 0 && w < 4 {
+			if p := get(w); p != nil {
+				p.Digs++
+				p.DigPoints += next.BottomAward
+				p.MaxDigMul = next.BottomMul
+			}
+		}
+		for i := 0; i < 4; i++ {
+			p := get(i)
+			if p == nil {
+				continue
+			}
+			p.Rounds++
+			if sameTeam(next, i, next.CallerSeat) {
+				p.CallerRounds++
+				p.LevelsGained += next.CallerDelta
+				p.CallerLabels = map[string]int{next.RoundResultLabel: 1}
+			} else {
+				p.DefenderRounds++
+				p.LevelsGained += next.DefenderDelta
+				p.DefenderLabels = map[string]int{next.RoundResultLabel: 1}
+			}
+			if next.Match != nil {
+				p.Matches++
+				if next.Seats[i].Team == next.Match.WinnerTeam {
+					p.MatchesWon++
+				}
+			}
+		}
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// leading 本墩还没有人出牌
+func leading(st *game.GameState) bool {
+	for _, pm := range st.Trick.Plays {
+		if pm != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func sameTeam(st *game.GameState, a, b int) bool {
+	if a < 0 || a > 3 || b < 0 || b > 3 {
+		return false
+	}
+	return st.Seats[a].Team == st.Seats[b].Team
+}
+
+func trickPoints(plays [4]*game.PlayedMove) int {
+	sum := 0
+	for _, pm := range plays {
+		if pm != nil {
+			sum += rules.TrickPoints(pm.Cards)
+		}
+	}
+	return sum
+}
@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"net/http"
+	"strconv"
+
+	"upgrade-lan/internal/game"
+	"upgrade-lan/internal/httpx"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 200
+)
+
+// Board 排行榜响应
+type Board struct {
+	Sort    string        `json:"sort"`
+	Players []PlayerStats `json:"players"`
+}
+
+// ServeStats HTTP GET /stats
+// - ?uid=xxx                单个玩家的详细统计
+// - ?sort=levels&limit=20   排行榜，sort 可选 levels/points/tricks/digs/rounds/matches
+func (b *Book) ServeStats(w http.ResponseWriter, r *http.Request) {
+	if httpx.AllowCORS(w, r, "GET") {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	if q.Has("uid") {
+		p, ok := b.Get(q.Get("uid"))
+		if !ok {
+			httpx.WriteJSON(w, http.StatusNotFound, game.ErrStatsNoPlayer.WithInfof("没有玩家 %s 的统计", q.Get("uid")))
+			return
+		}
+		httpx.WriteJSON(w, http.StatusOK, p)
+		return
+	}
+
+	key := q.Get("sort")
+	if key == "" {
+		key = "levels"
+	}
+	limit := defaultLimit
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			httpx.WriteJSON(w, http.StatusBadRequest, game.ErrInvalidPayload.WithInfo("limit 需为正整数"))
+			return
+		}
+		limit = min(n, maxLimit)
+	}
+	list, ok := b.Leaderboard(key, limit)
+	if !ok {
+		httpx.WriteJSON(w, http.StatusBadRequest, game.ErrStatsBadSort.WithInfof("不支持按 %s 排序", key))
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, Board{Sort: key, Players: list})
+}
@@ -0,0 +1,236 @@
+package stats
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"upgrade-lan/internal/store"
+)
+
+// PlayerStats 单个 uid 跨小局、跨整局的累计统计
+type PlayerStats struct {
+	UID string `json:"uid"`
+
+	Rounds         int `json:"rounds"`         // 打完的小局数
+	CallerRounds   int `json:"callerRounds"`   // 其中作为坐家
+	DefenderRounds int `json:"defenderRounds"` // 其中作为打家
+	LevelsGained   int `json:"levelsGained"`   // 本队在这些小局中累计升级
+
+	TricksWon      int `json:"tricksWon"`      // 赢下的墩数
+	PointsCaptured int `json:"pointsCaptured"` // 作为打家赢墩吃到的分（不含抠底）
+	Throws         int `json:"throws"`         // 先手甩牌次数
+	ThrowsOK       int `json:"throwsOk"`       // 其中甩牌成功
+	Digs           int `json:"digs"`           // 末墩抠底得分次数
+	DigPoints      int `json:"digPoints"`      // 抠底加分（含倍数）
+	MaxDigMul      int `json:"maxDigMul"`      // 抠底最大倍数
+
+	CallerLabels   map[string]int `json:"callerLabels,omitempty"`   // 作为坐家时的小局结果（光头/过小关…）
+	DefenderLabels map[string]int `json:"defenderLabels,omitempty"` // 作为打家时的小局结果（满分/大胜…）
+
+	Matches    int `json:"matches"`    // 打完的整局数
+	MatchesWon int `json:"matchesWon"` // 其中获胜
+
+	LastPlayed time.Time `json:"lastPlayed"`
+}
+
+// merge 累加（sign=1）或撤销（sign=-1，悔棋）一份增量；MaxDigMul 不可撤销
+func (p *PlayerStats) merge(d *PlayerStats, sign int) {
+	p.Rounds += sign * d.Rounds
+	p.CallerRounds += sign * d.CallerRounds
+	p.DefenderRounds += sign * d.DefenderRounds
+	p.LevelsGained += sign * d.LevelsGained
+	p.TricksWon += sign * d.TricksWon
+	p.PointsCaptured += sign * d.PointsCaptured
+	p.Throws += sign * d.Throws
+	p.ThrowsOK += sign * d.ThrowsOK
+	p.Digs += sign * d.Digs
+	p.DigPoints += sign * d.DigPoints
+	if sign > 0 {
+		p.MaxDigMul = max(p.MaxDigMul, d.MaxDigMul)
+		p.LastPlayed = time.Now()
+	}
+	p.CallerLabels = mergeLabels(p.CallerLabels, d.CallerLabels, sign)
+	p.DefenderLabels = mergeLabels(p.DefenderLabels, d.DefenderLabels, sign)
+	p.Matches += sign * d.Matches
+	p.MatchesWon += sign * d.MatchesWon
+}
+
+func mergeLabels(dst, d map[string]int, sign int) map[string]int {
+	for k, n := range d {
+		if dst == nil {
+			dst = make(map[string]int)
+		}
+		dst[k] += sign * n
+		if dst[k] <= 0 {
+			delete(dst, k)
+		}
+	}
+	return dst
+}
+
+func (p PlayerStats) clone() PlayerStats {
+	p.CallerLabels = mergeLabels(nil, p.CallerLabels, 1)
+	p.DefenderLabels = mergeLabels(nil, p.DefenderLabels, 1)
+	return p
+}
+
+// Book 所有玩家的统计（并发安全）；由各房间写入，HTTP /stats 读取
+// 落盘为数据目录下 stats/players.json，写入后由 FlushLoop 定期保存
+type Book struct {
+	mu      sync.Mutex
+	players map[string]*PlayerStats
+	store   *store.Store // 可能为 nil（不落盘）
+	dirty   bool
+}
+
+const storeKey = "players"
+
+// Open dir 为空时只在内存中统计
+func Open(dir string) *Book {
+	b := &Book{players: make(map[string]*PlayerStats)}
+	if dir == "" {
+		return b
+	}
+	s, err := store.New(dir)
+	if err != nil {
+		slog.Warn("统计目录打开失败", "dir", dir, "err", err)
+		return b
+	}
+	b.store = s
+	saved, err := s.LoadAll()
+	if err != nil {
+		slog.Warn("读取统计数据失败", "dir", dir, "err", err)
+		return b
+	}
+	if raw, ok := saved[storeKey]; ok {
+		var list []*PlayerStats
+		if err := json.Unmarshal(raw, &list); err != nil {
+			slog.Warn("统计数据解析失败", "dir", dir, "err", err)
+			return b
+		}
+		for _, p := range list {
+			b.players[p.UID] = p
+		}
+	}
+	return b
+}
+
+// Add 累加一次事件产生的增量
+func (b *Book) Add(deltas map[string]*PlayerStats) {
+	b.apply(deltas, 1)
+}
+
+// Sub 撤销一次事件产生的增量（悔棋）
+func (b *Book) Sub(deltas map[string]*PlayerStats) {
+	b.apply(deltas, -1)
+}
+
+func (b *Book) apply(deltas map[string]*PlayerStats, sign int) {
+	if b == nil || len(deltas) == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for uid, d := range deltas {
+		p := b.players[uid]
+		if p == nil {
+			p = &PlayerStats{UID: uid}
+			b.players[uid] = p
+		}
+		p.merge(d, sign)
+	}
+	b.dirty = true
+}
+
+// Get 单个玩家的统计
+func (b *Book) Get(uid string) (PlayerStats, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, ok := b.players[uid]
+	if !ok {
+		return PlayerStats{}, false
+	}
+	return p.clone(), true
+}
+
+// 排行榜可用的排序字段
+var sortKeys = map[string]func(p *PlayerStats) int{
+	"levels":  func(p *PlayerStats) int { return p.LevelsGained },
+	"points":  func(p *PlayerStats) int { return p.PointsCaptured },
+	"tricks":  func(p *PlayerStats) int { return p.TricksWon },
+	"digs":    func(p *PlayerStats) int { return p.DigPoints },
+	"rounds":  func(p *PlayerStats) int { return p.Rounds },
+	"matches": func(p *PlayerStats) int { return p.MatchesWon },
+}
+
+// Leaderboard 按 key 降序（相同时按小局数升序、uid 升序），最多 limit 条
+func (b *Book) Leaderboard(key string, limit int) ([]PlayerStats, bool) {
+	score, ok := sortKeys[key]
+	if !ok {
+		return nil, false
+	}
+	b.mu.Lock()
+	list := make([]*PlayerStats, 0, len(b.players))
+	for _, p := range b.players {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		si, sj := score(list[i]), score(list[j])
+		if si != sj {
+			return si > sj
+		}
+		if list[i].Rounds != list[j].Rounds {
+			return list[i].Rounds < list[j].Rounds
+		}
+		return list[i].UID < list[j].UID
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	out := make([]PlayerStats, len(list))
+	for i, p := range list {
+		out[i] = p.clone()
+	}
+	b.mu.Unlock()
+	return out, true
+}
+
+// Flush 有变化时落盘
+func (b *Book) Flush() error {
+	b.mu.Lock()
+	if b.store == nil || !b.dirty {
+		b.mu.Unlock()
+		return nil
+	}
+	list := make([]*PlayerStats, 0, len(b.players))
+	for _, p := range b.players {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UID < list[j].UID })
+	raw, err := json.Marshal(list)
+	b.dirty = false
+	b.mu.Unlock()
+	if err == nil {
+		err = b.store.Save(storeKey, raw)
+	}
+	if err != nil {
+		b.mu.Lock()
+		b.dirty = true // 下次重试
+		b.mu.Unlock()
+	}
+	return err
+}
+
+// FlushLoop 每隔 interval 落盘一次（阻塞，需在单独的 goroutine 中运行）
+func (b *Book) FlushLoop(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for range t.C {
+		if err := b.Flush(); err != nil {
+			slog.Warn("统计数据落盘失败", "err", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store 以“一个房间一个文件”的方式把房间数据落盘，写入是原子的（临时文件 + rename）
+type Store struct {
+	dir string
+}
+
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) path(roomID string) string {
+	return filepath.Join(s.dir, url.PathEscape(roomID)+".json")
+}
+
+// Save 原子写：先写同目录临时文件并 fsync，再 rename 覆盖
+func (s *Store) Save(roomID string, data []byte) error {
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // rename 成功后为 no-op
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, s.path(roomID))
+}
+
+func (s *Store) Delete(roomID string) error {
+	err := os.Remove(s.path(roomID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Load 读取单个房间；不存在时返回 os.ErrNotExist
+func (s *Store) Load(roomID string) ([]byte, error) {
+	return os.ReadFile(s.path(roomID))
+}
+
+// LoadAll 读取目录下所有房间：roomID -> 数据
+func (s *Store) LoadAll() (map[string][]byte, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		roomID, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("非法房间文件名 %s: %w", name, err)
+		}
+		b, err := os.ReadFile(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		out[roomID] = b
+	}
+	return out, nil
+}
@@ -5,6 +5,15 @@ package transport
 type Client interface {
 	UID() string
 	RoomID() string
+	Spectator() bool          // 以观战身份加入：只能看公开信息，不能操作
+	Credentials() Credentials // 握手时携带的私密房间凭据
+	Locale() string           // 握手时选择的显示语言（?lang= 或 Accept-Language），用于渲染通知文案
 	SendJSON(v any) error
 	Close() error
 }
+
+// Credentials 加入私密房间的凭据（?password= / ?invite=），已准入的 uid 重连时不需要
+type Credentials struct {
+	Password string
+	Invite   string
+}
@@ -4,12 +4,11 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"strings"
 	"sync"
 	"time"
-	"unicode"
 
 	"github.com/gorilla/websocket"
+	"upgrade-lan/internal/i18n"
 	"upgrade-lan/internal/transport"
 )
 
@@ -19,14 +18,20 @@ var upgrader = websocket.Upgrader{
 
 type Envelope struct {
 	Type    string          `json:"type"`
+	ReqID   string          `json:"reqId,omitempty"` // 可选：客户端请求 ID，用于 ack 与幂等去重
 	Payload json.RawMessage `json:"payload"`
 }
 
+// Authenticator 从握手请求中确定连接的 uid（session.Auth 实现）
+type Authenticator interface {
+	Authenticate(r *http.Request) (uid string, err error)
+}
+
 // Router ws 收到消息后交给上层（room.Manager）处理
 type Router interface {
-	OnConnect(c transport.Client)
+	OnConnect(c transport.Client) error // 返回错误时拒绝该连接（如房间不存在）
 	OnDisconnect(c transport.Client)
-	OnMessage(c transport.Client, typ string, payload json.RawMessage)
+	OnMessage(c transport.Client, typ string, reqID string, payload json.RawMessage)
 }
 
 type Conn struct {
@@ -36,8 +41,11 @@ type Conn struct {
 
 	closeOnce sync.Once
 
-	uid    string
-	roomID string
+	uid       string
+	roomID    string
+	spectator bool
+	cred      transport.Credentials
+	locale    i18n.Locale
 }
 
 type HelloMsg struct {
@@ -45,8 +53,13 @@ type HelloMsg struct {
 	UID  string `json:"uid"`
 }
 
-func (c *Conn) UID() string    { return c.uid }
-func (c *Conn) RoomID() string { return c.roomID }
+func (c *Conn) UID() string     { return c.uid }
+func (c *Conn) RoomID() string  { return c.roomID }
+func (c *Conn) Spectator() bool { return c.spectator }
+func (c *Conn) Credentials() transport.Credentials {
+	return c.cred
+}
+func (c *Conn) Locale() string { return string(c.locale) }
 
 func (c *Conn) SendJSON(v any) error {
 	b, err := json.Marshal(v)
@@ -62,29 +75,32 @@ func (c *Conn) SendJSON(v any) error {
 		return websocket.ErrCloseSent
 	default:
 		// 发送队列满，认为连接异常
+		metricSendDropped.Inc()
 		return websocket.ErrCloseSent
 	}
 }
 
+// Close 通知所有 goroutine 退出；底层 websocket 由 writeLoop 写完已排队的消息（被踢、被顶号等通知）后关闭
 func (c *Conn) Close() error {
-	var err error
 	c.closeOnce.Do(func() {
-		close(c.done)      // 通知所有 goroutine 退出
-		err = c.ws.Close() // 关闭底层 websocket
+		untrackConn(c)
+		close(c.done)
 	})
-	return err
+	return nil
 }
 
-func ServeWS(hub *Hub, router Router, w http.ResponseWriter, r *http.Request) {
+func ServeWS(hub *Hub, router Router, auth Authenticator, w http.ResponseWriter, r *http.Request) {
 	wsConn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println("upgrade:", err)
 		return
 	}
 
-	uid := normalizeAnyUID(r.URL.Query().Get("uid"))
-	if uid == "" {
-		uid = time.Now().Format("150405")
+	// 先升级再鉴权：浏览器拿不到握手失败的 HTTP 状态码，通过 error 消息告知原因
+	uid, err := auth.Authenticate(r)
+	if err != nil {
+		reject(wsConn, err)
+		return
 	}
 
 	roomID := r.URL.Query().Get("room")
@@ -92,16 +108,38 @@ func ServeWS(hub *Hub, router Router, w http.ResponseWriter, r *http.Request) {
 		roomID = "default"
 	}
 
+	// ?spectate=1 以观战身份加入
+	spectate := r.URL.Query().Get("spectate")
+	spectator := spectate == "1" || spectate == "true"
+
+	// ?lang=en 优先，其次 Accept-Language，默认中文
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		lang = r.Header.Get("Accept-Language")
+	}
+
 	c := &Conn{
-		ws:     wsConn,
-		send:   make(chan []byte, 64),
-		done:   make(chan struct{}),
-		uid:    uid,
-		roomID: roomID,
+		ws:        wsConn,
+		send:      make(chan []byte, 64),
+		done:      make(chan struct{}),
+		uid:       uid,
+		roomID:    roomID,
+		spectator: spectator,
+		cred: transport.Credentials{
+			Password: r.URL.Query().Get("password"),
+			Invite:   r.URL.Query().Get("invite"),
+		},
+		locale: i18n.Match(lang),
 	}
 
+	// 先入房再登记到 hub：房间不存在、密码错误等被拒绝的握手不能顶掉同 uid 的在线连接
+	trackConn(c)
+	if err := router.OnConnect(c); err != nil {
+		untrackConn(c)
+		reject(wsConn, err)
+		return
+	}
 	hub.register <- c
-	router.OnConnect(c)
 
 	go c.writeLoop()
 	c.readLoop(router)
@@ -133,21 +171,18 @@ func (c *Conn) readLoop(router Router) {
 
 		var env Envelope
 		if err := json.Unmarshal(data, &env); err != nil {
-			_ = c.SendJSON(map[string]any{
-				"type":    "error",
-				"code":    "bad_json",
-				"message": "invalid json envelope",
-			})
+			_ = c.SendJSON(badEnvelope(data, err))
 			continue
 		}
 
-		router.OnMessage(c, env.Type, env.Payload)
+		router.OnMessage(c, env.Type, env.ReqID, env.Payload)
 	}
 }
 
 func (c *Conn) writeLoop() {
 	ticker := time.NewTicker(20 * time.Second)
 	defer ticker.Stop()
+	defer c.ws.Close() // 关闭底层 websocket，readLoop 随之退出
 
 	for {
 		select {
@@ -166,29 +201,70 @@ func (c *Conn) writeLoop() {
 			}
 
 		case <-c.done:
+			c.flush()
 			return
 		}
 	}
 }
 
-func normalizeAnyUID(raw string) string {
-	s := strings.TrimSpace(raw)
-	if s == "" {
-		return ""
+// flush 关闭前写出仍在队列中的消息并发送 close 帧；对端已断开时写入很快失败
+func (c *Conn) flush() {
+	_ = c.ws.SetWriteDeadline(time.Now().Add(2 * time.Second))
+	for {
+		select {
+		case msg := <-c.send:
+			if err := c.ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		default:
+			_ = c.ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		}
+	}
+}
+
+// 同 game.ErrBadJSON（ws 不依赖 game 包）
+const (
+	codeBadJSON     = "PROTO_BAD_JSON"
+	categoryInvalid = "invalid"
+	messageBadJSON  = "JSON 数据格式错误"
+)
+
+// badEnvelope 信封解析失败时的 error 消息，与 game.ErrorMsg 同构；
+// 只是字段类型不对（如 payload 不是对象）时尽量带回 type 与 reqId，便于客户端对应到出错的命令
+func badEnvelope(data []byte, err error) map[string]any {
+	msg := map[string]any{
+		"type":     "error",
+		"code":     codeBadJSON,
+		"category": categoryInvalid,
+		"message":  messageBadJSON,
+		"info":     "消息信封解析失败: " + err.Error(),
 	}
-	// 移除控制字符
-	s = strings.Map(func(r rune) rune {
-		if unicode.IsControl(r) {
-			return -1
+	var loose map[string]any
+	if json.Unmarshal(data, &loose) == nil {
+		if typ, ok := loose["type"].(string); ok && typ != "" {
+			msg["event"] = typ
 		}
-		return r
-	}, s)
-
-	// 限制长度
-	const maxRunes = 24
-	rs := []rune(s)
-	if len(rs) > maxRunes {
-		s = string(rs[:maxRunes])
+		if reqID, ok := loose["reqId"].(string); ok && reqID != "" {
+			msg["reqId"] = reqID
+		}
+	}
+	return msg
+}
+
+// reject 拒绝连接：writeLoop 尚未启动，直接写回错误后关闭
+func reject(wsConn *websocket.Conn, err error) {
+	_ = wsConn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	var msg any = map[string]any{
+		"type":     "error",
+		"category": "system",
+		"message":  err.Error(),
+	}
+	// *game.AppError 自带完整的错误消息（code / category / info）
+	if we, ok := err.(interface{ WireMessage() any }); ok {
+		msg = we.WireMessage()
 	}
-	return s
+	_ = wsConn.WriteJSON(msg)
+	_ = wsConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, ""))
+	_ = wsConn.Close()
 }
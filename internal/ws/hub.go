@@ -1,5 +1,10 @@
 package ws
 
+import "upgrade-lan/internal/i18n"
+
+// noticeReplaced 同 game.NtSessionReplaced（ws 不依赖 game 包）
+const noticeReplaced = "session.replaced"
+
 type Hub struct {
 	register   chan *Conn
 	unregister chan *Conn
@@ -16,12 +21,11 @@ func NewHub() *Hub {
 }
 
 /*
-用户不填 UID → 前端连接：/ws?room=room1
-后端生成 anon-150405.000 → hello.uid=anon-... → seat/online 逻辑完全照旧
+前端先 POST /session {name: "alice"} → 得到 token（uid=alice#k3m9）
+前端连接：/ws?room=room1&token=... → hello.uid=alice#k3m9
+此后：入座、断线重连、snapshot 都以该 uid 身份一致；重连带同一个 token 即回到原座位
 
-用户填 UID=alice → 前端连接：/ws?room=room1&uid=alice
-后端采用 alice → hello.uid=alice
-此后：入座、断线重连、snapshot 都以 alice 身份一致
+-open-lan 模式下也可以不带 token：/ws?room=room1&uid=alice（不填则按时间生成）
 
 两个客户端都用 alice（如果启用 Hub 冲突处理）
 后连接者顶掉旧连接，旧连接收到 notice 并被 close
@@ -35,9 +39,11 @@ func (h *Hub) Run() {
 			if old, ok := h.byUID[c.uid]; ok && old != c {
 				_ = old.SendJSON(map[string]any{
 					"type":    "notice",
-					"message": "该UID在其他位置登录，你已被顶下线",
+					"code":    noticeReplaced,
+					"message": i18n.Render(old.locale, noticeReplaced, nil),
 				})
 				_ = old.Close()
+				metricHubKicks.Inc()
 			}
 			h.byUID[c.uid] = c
 
@@ -0,0 +1,53 @@
+package ws
+
+import (
+	"sync"
+
+	"upgrade-lan/internal/metrics"
+)
+
+var (
+	metricSendDropped = metrics.NewCounter("upgrade_ws_send_dropped_total", "SendJSON 因发送队列已满而丢弃的消息数")
+	metricHubKicks    = metrics.NewCounter("upgrade_ws_hub_kicks_total", "同 UID 重复登录时被顶下线的连接数")
+)
+
+// live 当前存活的连接，仅用于抓取 send 队列深度（Hub.byUID 只在 Hub.Run 中访问）
+var live struct {
+	mu    sync.Mutex
+	conns map[*Conn]struct{}
+}
+
+func trackConn(c *Conn) {
+	live.mu.Lock()
+	if live.conns == nil {
+		live.conns = make(map[*Conn]struct{})
+	}
+	live.conns[c] = struct{}{}
+	live.mu.Unlock()
+}
+
+func untrackConn(c *Conn) {
+	live.mu.Lock()
+	delete(live.conns, c)
+	live.mu.Unlock()
+}
+
+// send 队列按连接打标签会随 uid 无限增长，这里只输出汇总
+func init() {
+	metrics.NewGaugeFunc("upgrade_ws_connections", "存活的 WebSocket 连接数", "", func() map[string]float64 {
+		live.mu.Lock()
+		defer live.mu.Unlock()
+		return map[string]float64{"": float64(len(live.conns))}
+	})
+	metrics.NewGaugeFunc("upgrade_ws_send_queue_depth", "所有连接 send 队列中待写出的消息数（sum/max）", "stat", func() map[string]float64 {
+		live.mu.Lock()
+		defer live.mu.Unlock()
+		sum, peak := 0, 0
+		for c := range live.conns {
+			n := len(c.send)
+			sum += n
+			peak = max(peak, n)
+		}
+		return map[string]float64{"sum": float64(sum), "max": float64(peak)}
+	})
+}